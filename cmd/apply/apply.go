@@ -0,0 +1,374 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package apply
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/settings"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Cmd gets the apply command.
+func Cmd() *cobra.Command {
+	applyCmd := &cobra.Command{
+		Use:   "apply -f FILE [CONNECTION_OR_GROUP...]",
+		Short: "Apply desired settings from a manifest.",
+		Long: dedent.Dedent(fmt.Sprintf(`Apply the desired settings in a manifest to the connections and groups
+		it names, the given connections and groups, or the default connection.
+
+		The settings of each connection are compared with the manifest to plan
+		the requests that change them. The plan is shown, and once confirmed,
+		each step is made and its result shown. Settings that already have the
+		desired value are left alone, so applying a manifest again only
+		changes what has drifted.
+
+		The manifest is a YAML file with any of these sections:
+
+		  %s
+
+		Each section holds the Redfish properties to set, for example:
+
+		  targets: [web01, web02]
+		  bios:
+		    ProcTurboMode: Disabled
+		  boot:
+		    BootOrder: [Boot0002, Boot0001]
+		  ntp:
+		    ProtocolEnabled: true
+		    NTPServers: [0.pool.ntp.org, 1.pool.ntp.org]
+		  dns:
+		    HostName: web01-bmc
+		    StaticNameServers: [192.168.0.2]
+		  network_protocols:
+		    IPMI: {ProtocolEnabled: false}
+		  users:
+		    deploy: {RoleId: Operator, Enabled: true, Password: changeme}
+		    olduser: null
+		  subscriptions:
+		    https://collector.example.com/events: {Context: lab, RegistryPrefixes: [ResourceEvent]}
+		  indicator_led: Lit
+		  asset_tag: RACK-A1-U12
+
+		Users and subscriptions that are not listed are left alone, and those
+		set to null are deleted. A Password is only needed to create a user.
+
+		BIOS changes take effect once the system is reset, which is done after
+		the changes are made with --reset. BIOS attributes that the attribute
+		registry of the service marks as read-only are left alone.`, strings.Join(settings.Sections(), ", "))),
+		RunE: applyManifest,
+	}
+
+	applyCmd.Flags().StringP("file", "f", "", "Manifest `FILE` with the desired settings.")
+	AddFlags(applyCmd)
+	applyCmd.Flags().SortFlags = true
+	_ = applyCmd.MarkFlagRequired("file")
+
+	return applyCmd
+}
+
+// AddFlags adds the flags used by Settings to a command.
+func AddFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "Show the plan without changing anything.")
+	cmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation.")
+	cmd.Flags().Bool("reset", false, "Reset the system if changes need a reset to take effect.")
+}
+
+// resetType is how systems are reset when changes need it.
+const resetType = redfish.GracefulRestartResetType
+
+// applyManifest plans and makes the changes in a manifest.
+func applyManifest(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	manifest, err := settings.LoadManifest(file)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	names := args
+	if len(names) == 0 {
+		names = manifest.Targets
+	}
+
+	connections, err := utils.Connections(names)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	return Settings(cmd, connections, manifest.Settings)
+}
+
+// target is the plan and results of one connection.
+type target struct {
+	connection string
+	steps      []*settings.Step
+	// skipped are the settings left out as the connection cannot take them.
+	skipped []settings.Skipped
+	// results are the errors of each step once applied.
+	results []error
+	// reset is set once the system was reset, with resetErr if that failed.
+	reset    bool
+	resetErr error
+	err      error
+}
+
+// Settings plans the changes to give each connection the desired settings,
+// shows the plan, and makes the changes once confirmed. The command must have
+// the flags added by AddFlags.
+func Settings(cmd *cobra.Command, connections []string, desired settings.Settings) error {
+	return applySettings(cmd, connections, desired, false)
+}
+
+// AvailableSettings is like Settings, but settings a connection cannot take,
+// such as those for a chassis it does not have, are skipped with a warning
+// and the rest are still changed.
+func AvailableSettings(cmd *cobra.Command, connections []string, desired settings.Settings) error {
+	return applySettings(cmd, connections, desired, true)
+}
+
+// applySettings plans, shows and makes the changes, skipping the settings a
+// connection cannot take if skipUnavailable is set.
+func applySettings(cmd *cobra.Command, connections []string, desired settings.Settings, skipUnavailable bool) error {
+	targets := make([]*target, len(connections))
+	utils.ForEachConnection(connections, func(i int, connection string) {
+		targets[i] = &target{connection: connection}
+		targets[i].steps, targets[i].skipped, targets[i].err = plan(connection, desired, skipUnavailable)
+	})
+
+	failed := 0
+	changes := 0
+	for _, t := range targets {
+		for _, skipped := range t.skipped {
+			cmd.PrintErrf("Skipping %s on %s: %s\n", skipped.Path, utils.DescribeConnection(t.connection), skipped.Reason)
+		}
+		if t.err != nil {
+			cmd.PrintErrf("Unable to plan %s: %v\n", utils.DescribeConnection(t.connection), t.err)
+			failed++
+		}
+		changes += len(t.steps)
+	}
+
+	if changes == 0 {
+		if failed > 0 {
+			return utils.ErrorExit(cmd, "unable to plan %d of %d connections", failed, len(connections))
+		}
+		cmd.Println("Nothing to change, the settings are already as desired.")
+		return nil
+	}
+
+	printPlan(cmd, targets)
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	yes, _ := cmd.Flags().GetBool("yes")
+	switch {
+	case dryRun:
+		cmd.Printf("%d changes planned.\n", changes)
+		return planFailures(cmd, failed, len(connections))
+	case !yes && !utils.Confirm(cmd, "Apply %d changes to %s?", changes, planned(targets)):
+		cmd.Println("Nothing was changed.")
+		return nil
+	}
+
+	reset, _ := cmd.Flags().GetBool("reset")
+	utils.ForEachConnection(connections, func(i int, _ string) {
+		if len(targets[i].steps) > 0 {
+			targets[i].apply(reset)
+		}
+	})
+
+	return printResults(cmd, targets, failed, len(connections))
+}
+
+// plan works out the steps to give a connection the desired settings.
+func plan(connection string, desired settings.Settings, skipUnavailable bool) ([]*settings.Step, []settings.Skipped, error) {
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Logout()
+
+	if skipUnavailable {
+		return settings.PlanAvailable(c, desired)
+	}
+
+	steps, err := settings.Plan(c, desired)
+	return steps, nil, err
+}
+
+// apply makes each step of the plan, then resets the system if asked to and
+// a change needs it. Steps are made even if earlier ones fail, as they are
+// independent, but the system is only reset if all steps succeeded.
+func (t *target) apply(reset bool) {
+	t.results = make([]error, len(t.steps))
+
+	c, err := utils.GofishClient(t.connection)
+	if err != nil {
+		for i := range t.results {
+			t.results[i] = err
+		}
+		return
+	}
+	defer c.Logout()
+
+	for i, step := range t.steps {
+		t.results[i] = step.Apply(c)
+	}
+
+	if !reset || !t.needsReset() || t.failures() > 0 {
+		return
+	}
+
+	t.reset = true
+	systems, err := c.Service.Systems()
+	switch {
+	case err != nil:
+		t.resetErr = err
+	case len(systems) == 0:
+		t.resetErr = utils.Error("no system to reset")
+	default:
+		t.resetErr = systems[0].Reset(resetType)
+	}
+}
+
+// needsReset checks if any change only takes effect once the system is reset.
+func (t *target) needsReset() bool {
+	for _, step := range t.steps {
+		if step.Reboot {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failures counts the steps that failed.
+func (t *target) failures() int {
+	count := 0
+	for _, err := range t.results {
+		if err != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// printPlan shows the steps of each connection, with a row for each setting
+// a step changes.
+func printPlan(cmd *cobra.Command, targets []*target) {
+	headers := []string{"step", "method", "resource", "setting", "current", "desired"}
+	if len(targets) > 1 {
+		headers = append([]string{"connection"}, headers...)
+	}
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), headers...)
+
+	for _, t := range targets {
+		for i, step := range t.steps {
+			for j, change := range step.Changes {
+				row := []interface{}{"", "", "", change.Path, settings.FormatValue(change.Right), settings.FormatValue(change.Left)}
+				if j == 0 {
+					row[0], row[1], row[2] = i+1, step.Method, step.URI
+				}
+				if len(targets) > 1 {
+					row = append([]interface{}{utils.DescribeConnection(t.connection)}, row...)
+				}
+				writer.AddRow(row...)
+			}
+		}
+	}
+
+	writer.Render()
+}
+
+// printResults shows the result of each step, and of resetting the system.
+func printResults(cmd *cobra.Command, targets []*target, planFailed, total int) error {
+	headers := []string{"step", "method", "resource", "result"}
+	if len(targets) > 1 {
+		headers = append([]string{"connection"}, headers...)
+	}
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), headers...)
+
+	addRow := func(t *target, step interface{}, method, resource string, err error) {
+		row := []interface{}{step, method, resource, result(err)}
+		if len(targets) > 1 {
+			row = append([]interface{}{utils.DescribeConnection(t.connection)}, row...)
+		}
+		if err != nil {
+			writer.AddHighlightedRow(utils.SeverityCritical, row...)
+		} else {
+			writer.AddRow(row...)
+		}
+	}
+
+	failed := 0
+	applied := 0
+	pendingReset := []string{}
+	for _, t := range targets {
+		for i, step := range t.steps {
+			addRow(t, i+1, step.Method, step.URI, t.results[i])
+			applied++
+		}
+		failed += t.failures()
+
+		switch {
+		case t.reset:
+			addRow(t, "reset", string(resetType), "system", t.resetErr)
+			if t.resetErr != nil {
+				failed++
+			}
+		case t.needsReset() && t.failures() < len(t.steps):
+			pendingReset = append(pendingReset, utils.DescribeConnection(t.connection))
+		}
+	}
+
+	writer.Render()
+
+	if len(pendingReset) > 0 {
+		cmd.Printf("Some changes take effect once the system of %s is reset.\n", strings.Join(pendingReset, ", "))
+	}
+
+	if failed > 0 {
+		return utils.ErrorExit(cmd, "%d of %d changes failed", failed, applied)
+	}
+	cmd.Printf("Applied %d changes.\n", applied)
+
+	return planFailures(cmd, planFailed, total)
+}
+
+// planFailures gives an error if any connection could not be planned.
+func planFailures(cmd *cobra.Command, failed, total int) error {
+	if failed > 0 {
+		return utils.ErrorExit(cmd, "unable to plan %d of %d connections", failed, total)
+	}
+
+	return nil
+}
+
+// result describes the outcome of a step.
+func result(err error) string {
+	if err != nil {
+		return utils.ErrorMessage(err)
+	}
+
+	return "OK"
+}
+
+// planned names the connections with changes planned.
+func planned(targets []*target) string {
+	names := []string{}
+	for _, t := range targets {
+		if len(t.steps) > 0 {
+			names = append(names, utils.DescribeConnection(t.connection))
+		}
+	}
+
+	if len(names) > 3 {
+		return fmt.Sprintf("%d connections", len(names))
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package apply
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestApply(t *testing.T) {
+	server := mockuptest.Start(t)
+	manifest := mockuptest.WriteFile(t, "manifest.yaml", `
+targets: mockup
+boot:
+  BootOrder: [Boot0002, Boot0001]
+ntp:
+  NTPServers: [time.example.com]
+network_protocols:
+  IPMI: {ProtocolEnabled: false}
+  SSH: {ProtocolEnabled: false}
+users:
+  deploy: {RoleId: Operator, Password: changeme1}
+  operator: null
+subscriptions:
+  https://collector.example.com/events: {Context: lab}
+indicator_led: Lit
+asset_tag: RACK-B2
+`)
+
+	output, err := mockuptest.Run(t, Cmd(), "-f", manifest, "--yes")
+	if err != nil {
+		t.Fatalf("apply failed: %v\n%s", err, output)
+	}
+
+	// IPMI is already disabled so only SSH is changed
+	for _, expected := range []string{"network_protocols.SSH.ProtocolEnabled", "users.operator", "Applied 8 changes."} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "IPMI") {
+		t.Errorf("unchanged setting was planned:\n%s", output)
+	}
+
+	system, _ := server.Resource("/redfish/v1/Systems/1")
+	boot := system["Boot"].(map[string]interface{})
+	if !reflect.DeepEqual(boot["BootOrder"], []interface{}{"Boot0002", "Boot0001"}) {
+		t.Errorf("unexpected boot order: %v", boot["BootOrder"])
+	}
+	if system["IndicatorLED"] != "Lit" || system["AssetTag"] != "RACK-B2" {
+		t.Errorf("system was not updated: %v, %v", system["IndicatorLED"], system["AssetTag"])
+	}
+
+	if account, _ := server.Resource("/redfish/v1/AccountService/Accounts/3"); account["UserName"] != "deploy" {
+		t.Errorf("expected the deploy user to be created, got %v", account)
+	}
+	if _, found := server.Resource("/redfish/v1/AccountService/Accounts/2"); found {
+		t.Errorf("expected the operator user to be deleted")
+	}
+
+	subscription, _ := server.Resource("/redfish/v1/EventService/Subscriptions/1")
+	if subscription["Context"] != "lab" {
+		t.Errorf("expected the subscription context to change, got %v", subscription["Context"])
+	}
+
+	// Applying again has nothing to do
+	output, err = mockuptest.Run(t, Cmd(), "-f", manifest, "--yes")
+	if err != nil || !strings.Contains(output, "Nothing to change") {
+		t.Errorf("expected nothing to change, got %v:\n%s", err, output)
+	}
+}
+
+func TestApplyBIOS(t *testing.T) {
+	server := mockuptest.Start(t)
+	manifest := mockuptest.WriteFile(t, "manifest.yaml", "bios:\n  ProcTurboMode: Disabled\n  BootMode: Uefi\n")
+
+	output, err := mockuptest.Run(t, Cmd(), "-f", manifest, "--dry-run")
+	if err != nil || !strings.Contains(output, "/redfish/v1/Systems/1/Bios/Settings") || !strings.Contains(output, "1 changes planned.") {
+		t.Fatalf("expected the pending settings to be changed, got %v:\n%s", err, output)
+	}
+
+	// Without confirmation nothing is changed
+	output, err = mockuptest.Run(t, Cmd(), "-f", manifest)
+	if err != nil || !strings.Contains(output, "Nothing was changed.") {
+		t.Fatalf("expected nothing to be changed, got %v:\n%s", err, output)
+	}
+
+	output, err = mockuptest.Run(t, Cmd(), "-f", manifest, "--yes")
+	if err != nil || !strings.Contains(output, "take effect once the system of the default connection is reset") {
+		t.Fatalf("expected a reset to be needed, got %v:\n%s", err, output)
+	}
+
+	pending, _ := server.Resource("/redfish/v1/Systems/1/Bios/Settings")
+	if pending["Attributes"].(map[string]interface{})["ProcTurboMode"] != "Disabled" {
+		t.Errorf("expected the pending attribute to be set, got %v", pending["Attributes"])
+	}
+
+	// The pending change is not made again
+	output, err = mockuptest.Run(t, Cmd(), "-f", manifest, "--yes", "--reset")
+	if err != nil || !strings.Contains(output, "Nothing to change") {
+		t.Errorf("expected nothing to change, got %v:\n%s", err, output)
+	}
+}
+
+func TestApplyReset(t *testing.T) {
+	server := mockuptest.Start(t)
+	if err := server.Update("/redfish/v1/Systems/1", map[string]interface{}{"PowerState": "Off"}); err != nil {
+		t.Fatalf("unable to update mockup: %v", err)
+	}
+	manifest := mockuptest.WriteFile(t, "manifest.yaml", "bios:\n  ProcTurboMode: Disabled\n")
+
+	output, err := mockuptest.Run(t, Cmd(), "-f", manifest, "--yes", "--reset")
+	if err != nil || !strings.Contains(output, "GracefulRestart") {
+		t.Fatalf("expected the system to be reset, got %v:\n%s", err, output)
+	}
+
+	system, _ := server.Resource("/redfish/v1/Systems/1")
+	if system["PowerState"] != "On" {
+		t.Errorf("expected the system to be reset, got power state %v", system["PowerState"])
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		expected string
+	}{
+		{"unknown section", "bogus: {}\n", "unknown section 'bogus'"},
+		{"read only section", "firmware:\n  BIOS: P79\n", "the firmware section cannot be applied"},
+		{"new user without password", "users:\n  deploy: {RoleId: Operator}\n", "a Password is needed to create it"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockuptest.Start(t)
+
+			output, err := mockuptest.Run(t, Cmd(), "-f", mockuptest.WriteFile(t, "manifest.yaml", test.manifest), "--yes")
+			if err == nil || !strings.Contains(output, test.expected) {
+				t.Errorf("expected error containing %q, got %v:\n%s", test.expected, err, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/settings"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Cmd gets the backup command.
+func Cmd() *cobra.Command {
+	backupCmd := &cobra.Command{
+		Use:   "backup [CONNECTION]",
+		Short: "Save the settings of a connection to a file.",
+		Long: dedent.Dedent(fmt.Sprintf(`Save the settings of the default connection or the given connection to
+		a backup file, which can be restored to the same node or one that
+		replaces it with the restore command.
+
+		The backup holds these sections of settings:
+
+		  %s
+
+		Passwords of users and of the LDAP service cannot be read, so they
+		are not saved. The backup is written as YAML, with the same sections as
+		the manifests of the apply command under settings.`, strings.Join(settings.BackupSections, ", "))),
+		RunE: backupSettings,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	backupCmd.Flags().StringP("output", "o", "", "File to write the backup to instead of stdout.")
+	backupCmd.Flags().SortFlags = true
+
+	return backupCmd
+}
+
+// backupSettings reads the settings of a connection and writes them out.
+// Sections that could not be read are reported, and the rest are still saved.
+func backupSettings(cmd *cobra.Command, args []string) error {
+	connection := ""
+	if len(args) > 0 {
+		connection = args[0]
+	}
+
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	current, err := settings.Read(c, settings.BackupSections...)
+	var readErr *settings.ReadError
+	if err != nil && !errors.As(err, &readErr) {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	backup := settings.NewBackup(utils.ConnectionName(connection), current)
+	output, _ := cmd.Flags().GetString("output")
+	if err := write(cmd, output, backup); err != nil {
+		return utils.ErrorExit(cmd, "unable to write the backup: %v", err)
+	}
+
+	if readErr != nil {
+		for _, name := range settings.BackupSections {
+			if sectionErr, failed := readErr.Sections[name]; failed {
+				cmd.PrintErrf("Unable to back up %s: %s\n", name, utils.ErrorMessage(sectionErr))
+			}
+		}
+		return utils.ErrorExit(cmd, "unable to back up %d of %d sections", len(readErr.Sections), len(settings.BackupSections))
+	}
+
+	if output != "" {
+		cmd.Printf("Saved %d sections of settings from %s to %s.\n", len(current), backup.Source, output)
+	}
+
+	return nil
+}
+
+// write writes the backup to a file, or stdout if there is none. The file is
+// only readable by the user, as it describes the accounts of the node.
+func write(cmd *cobra.Command, output string, backup *settings.Backup) error {
+	if output == "" {
+		return backup.Write(cmd.OutOrStdout())
+	}
+
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = backup.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
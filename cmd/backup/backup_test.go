@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/settings"
+)
+
+func TestBackup(t *testing.T) {
+	mockuptest.Start(t)
+	path := filepath.Join(t.TempDir(), "web01.yaml")
+
+	output, err := mockuptest.Run(t, Cmd(), "-o", path)
+	if err != nil || !strings.Contains(output, "Saved 11 sections of settings from mockup") {
+		t.Fatalf("backup failed: %v\n%s", err, output)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected a file only the user can read, got %v, %v", info, err)
+	}
+
+	backup, err := settings.LoadBackup(path)
+	if err != nil {
+		t.Fatalf("unable to load the backup: %v", err)
+	}
+
+	if backup.Source != "mockup" {
+		t.Errorf("expected the backup to be from mockup, got %q", backup.Source)
+	}
+
+	for _, name := range settings.BackupSections {
+		if _, ok := backup.Settings[name]; !ok {
+			t.Errorf("backup is missing %s", name)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "Password:") {
+		t.Errorf("backup includes a password:\n%s", data)
+	}
+
+	limits := backup.Settings[settings.PowerLimit].(map[string]interface{})
+	if limits["1"].(map[string]interface{})["LimitInWatts"] != float64(500) {
+		t.Errorf("unexpected power limits: %v", limits)
+	}
+}
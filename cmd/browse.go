@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// browseCommands describes the commands understood by the browser.
+const browseCommands = `
+Commands:
+  ls               List the links of the current resource.
+  cd LINK          Go to a link, given as its number, property, or URI.
+                   "cd .." goes back and "cd /" goes to the service root.
+  show [PROPERTY]  Show the properties of the current resource.
+  json [PROPERTY]  Print the JSON of the current resource.
+  pwd              Print the URI of the current resource.
+  help             Show this list of commands.
+  exit             Leave the browser.`
+
+// browseCmd lets the user move through the resources of a service.
+var browseCmd = &cobra.Command{
+	Use:   "browse [URI]",
+	Short: "Interactively explore the resources of a service.",
+	Long: dedent.Dedent(`Interactively explore the resources of a service, starting at the service
+	root or the given URI. Commands are read one per line from stdin.
+	`) + browseCommands,
+	RunE: browse,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
+
+// browser keeps track of where the user is in the resource tree.
+type browser struct {
+	client   common.Client
+	out      io.Writer
+	history  []string
+	resource utils.Resource
+	links    []utils.ResourceLink
+}
+
+// browse runs the browser until the user exits or stdin is closed.
+func browse(cmd *cobra.Command, args []string) error {
+	uri := utils.ServiceRoot
+	if len(args) == 1 {
+		uri = strings.TrimSuffix(utils.ResourceURI(args[0]), "/")
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	b := &browser{client: c, out: cmd.OutOrStdout()}
+	err = b.visit(uri)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to get '%s': %v", uri, err)
+	}
+
+	scanner := bufio.NewScanner(cmd.InOrStdin())
+	for {
+		fmt.Fprintf(b.out, "%s> ", b.uri())
+		if !scanner.Scan() {
+			fmt.Fprintln(b.out)
+			return nil
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "exit" || fields[0] == "quit" {
+			return nil
+		}
+
+		err := b.run(fields[0], fields[1:])
+		if err != nil {
+			cmd.PrintErrln("Error:", utils.ErrorMessage(err))
+		}
+	}
+}
+
+// run performs a single browser command.
+func (b *browser) run(command string, args []string) error {
+	argument := strings.Join(args, " ")
+
+	switch command {
+	case "ls":
+		b.list()
+	case "cd":
+		return b.change(argument)
+	case "show":
+		b.show(argument)
+	case "json":
+		return b.printJSON(argument)
+	case "pwd":
+		fmt.Fprintln(b.out, b.uri())
+	case "help", "?":
+		fmt.Fprintln(b.out, strings.TrimPrefix(browseCommands, "\n"))
+	default:
+		return utils.Error("unknown command '%s', use 'help' to list the commands", command)
+	}
+
+	return nil
+}
+
+// uri gets the URI of the current resource.
+func (b *browser) uri() string {
+	return b.history[len(b.history)-1]
+}
+
+// visit loads a resource and makes it the current one.
+func (b *browser) visit(uri string) error {
+	resource, err := utils.GetResource(b.client, uri)
+	if err != nil {
+		return err
+	}
+
+	b.history = append(b.history, uri)
+	b.resource = resource
+	b.links = resource.Links()
+
+	return nil
+}
+
+// list prints the links of the current resource.
+func (b *browser) list() {
+	writer := utils.NewTableWriter(b.out, "#", "property", "uri", "kind")
+	for i, link := range b.links {
+		kind := "reference"
+		if link.Contained(b.uri()) {
+			kind = "child"
+		}
+		writer.AddRow(strconv.Itoa(i+1), link.Property, link.URI, kind)
+	}
+	writer.Render()
+}
+
+// change moves to another resource.
+func (b *browser) change(target string) error {
+	switch target {
+	case "":
+		return utils.Error("cd needs a link number, property, or URI")
+	case "..":
+		if len(b.history) > 1 {
+			return b.restart(b.history[:len(b.history)-2], b.history[len(b.history)-2])
+		}
+		return nil
+	case "/":
+		return b.restart(nil, utils.ServiceRoot)
+	}
+
+	if number, err := strconv.Atoi(target); err == nil {
+		if number < 1 || number > len(b.links) {
+			return utils.Error("there is no link number %d", number)
+		}
+		return b.visit(b.links[number-1].URI)
+	}
+
+	for _, link := range b.links {
+		if strings.EqualFold(link.Property, target) {
+			return b.visit(link.URI)
+		}
+	}
+
+	if !strings.HasPrefix(target, "/") && !strings.Contains(target, "://") {
+		target = b.uri() + "/" + target
+	}
+
+	return b.visit(strings.TrimSuffix(utils.ResourceURI(target), "/"))
+}
+
+// restart replaces the history before visiting a resource, keeping the old
+// history if the resource cannot be loaded.
+func (b *browser) restart(history []string, uri string) error {
+	previous := b.history
+	b.history = history
+
+	err := b.visit(uri)
+	if err != nil {
+		b.history = previous
+	}
+
+	return err
+}
+
+// show prints the properties of the current resource, optionally limited to
+// the properties below the given one.
+func (b *browser) show(property string) {
+	writer := utils.NewTableWriter(b.out, "property", "value")
+	for _, row := range flattenProperties("", map[string]interface{}(b.resource)) {
+		if property == "" || strings.EqualFold(row[0], property) ||
+			strings.HasPrefix(strings.ToLower(row[0]), strings.ToLower(property)+"/") {
+			writer.AddRow(row[0], row[1])
+		}
+	}
+	writer.Render()
+}
+
+// printJSON prints the current resource, or one of its properties, as JSON.
+func (b *browser) printJSON(property string) error {
+	var value interface{} = map[string]interface{}(b.resource)
+	if property != "" {
+		for _, key := range strings.Split(property, "/") {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				var found bool
+				value, found = v[key]
+				if !found {
+					return utils.Error("property '%s' was not found.", property)
+				}
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(v) {
+					return utils.Error("property '%s' was not found.", property)
+				}
+				value = v[i]
+			default:
+				return utils.Error("property '%s' was not found.", property)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(b.out, string(data))
+	return nil
+}
+
+// flattenProperties lists each value in a resource along with its property
+// path.
+func flattenProperties(path string, value interface{}) [][2]string {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "/" + key
+	}
+
+	rows := [][2]string{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && path != "" {
+			return append(rows, [2]string{path, "{}"})
+		}
+		for _, key := range utils.SortedKeys(v) {
+			rows = append(rows, flattenProperties(join(key), v[key])...)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return append(rows, [2]string{path, "[]"})
+		}
+		for i, item := range v {
+			rows = append(rows, flattenProperties(join(strconv.Itoa(i)), item)...)
+		}
+	case nil:
+		rows = append(rows, [2]string{path, "null"})
+	default:
+		rows = append(rows, [2]string{path, fmt.Sprint(v)})
+	}
+
+	return rows
+}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package clearcmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Clear object contents.",
+	}
+
+	clearCmd.AddCommand(logCmd)
+
+	return clearCmd
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package clearcmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var logCmd = &cobra.Command{
+	Use:     "log SERVICE",
+	Aliases: []string{"l"},
+	Short:   "Clear all entries of a log service.",
+	Long:    "Clears the log service with the given ID, name, or URI after asking for confirmation.",
+	RunE:    clearLog,
+	Args:    cobra.ExactArgs(1),
+}
+
+func init() {
+	logCmd.Flags().BoolP("yes", "y", false, "Do not ask for confirmation.")
+}
+
+// clearLog invokes the ClearLog action of a log service.
+func clearLog(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	info, err := utils.FindLogService(c.Service, args[0])
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes && !utils.Confirm(cmd, "Clear all entries of %q on %s?", info.Service.Name, info.Source) {
+		return utils.ErrorExit(cmd, "log was not cleared")
+	}
+
+	err = info.Service.ClearLog()
+	if err != nil {
+		return utils.ErrorExit(cmd, "error clearing log: %v", err)
+	}
+
+	cmd.Printf("Cleared %s.\n", info.Service.ODataID)
+	return nil
+}
@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package collect
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	collectCmd := &cobra.Command{
+		Use:   "collect",
+		Short: "Collect data for troubleshooting.",
+	}
+
+	collectCmd.AddCommand(diagnosticsCmd)
+	collectCmd.AddCommand(logsCmd)
+
+	return collectCmd
+}
@@ -0,0 +1,277 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package collect
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"os/signal"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish"
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var diagnosticsCmd = &cobra.Command{
+	Use:     "diagnostics",
+	Aliases: []string{"diagnostic", "diag"},
+	Short:   "Collect diagnostic data from a log service.",
+	Long: dedent.Dedent(`Collect diagnostic data using the CollectDiagnosticData action of a log service.
+
+	The service usually gathers the data in a background task. The task is
+	followed until it finishes, or until the time given with --wait has passed,
+	and the resulting attachment is downloaded to a local file.`),
+	RunE: collectDiagnostics,
+	Args: cobra.NoArgs,
+}
+
+var diagnosticDataTypes = []string{
+	string(redfish.ManagerLogDiagnosticDataTypes),
+	string(redfish.PreOSLogDiagnosticDataTypes),
+	string(redfish.OSLogDiagnosticDataTypes),
+	string(redfish.OEMLogDiagnosticDataTypes),
+}
+
+func init() {
+	diagnosticsCmd.Flags().String("type", "",
+		fmt.Sprintf("The type of diagnostic data to collect (%s).", strings.Join(diagnosticDataTypes, ", ")))
+	diagnosticsCmd.Flags().String("oem-type", "", "The OEM defined type of data to collect when --type is OEM.")
+	diagnosticsCmd.Flags().String("service", "", "Log service ID, name, or URI to collect from. Chosen automatically if not set.")
+	diagnosticsCmd.Flags().StringP("output", "o", "", "File to save the data to. Defaults to the name provided by the service.")
+	diagnosticsCmd.Flags().Duration("wait", 30*time.Minute, "How long to wait for the collection to finish.")
+	diagnosticsCmd.Flags().Duration("interval", 5*time.Second, "How often to check the progress of the collection.")
+	diagnosticsCmd.Flags().SortFlags = true
+
+	_ = diagnosticsCmd.MarkFlagRequired("type")
+}
+
+// collectDiagnostics starts the diagnostic data collection and downloads the
+// result.
+func collectDiagnostics(cmd *cobra.Command, _ []string) error {
+	dataType, _ := cmd.Flags().GetString("type")
+	idx := slices.IndexFunc(diagnosticDataTypes, func(t string) bool {
+		return strings.EqualFold(t, dataType)
+	})
+	if idx == -1 {
+		return utils.ErrorExit(
+			cmd, "invalid type '%s', must be one of: %s", dataType, strings.Join(diagnosticDataTypes, ", "))
+	}
+	dataType = diagnosticDataTypes[idx]
+
+	oemType, _ := cmd.Flags().GetString("oem-type")
+	if dataType == string(redfish.OEMLogDiagnosticDataTypes) && oemType == "" {
+		return utils.ErrorExit(cmd, "--oem-type is required when collecting OEM diagnostic data")
+	}
+
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		return utils.ErrorExit(cmd, "the interval must be more than 0")
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	serviceName, _ := cmd.Flags().GetString("service")
+	info, action, err := findDiagnosticService(c.Service, serviceName, dataType)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	// Remember the existing entries so the new one can be found if the
+	// service does not say where it put the data
+	existing, err := utils.LogEntries(info.Service)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve log entries: %v", err)
+	}
+
+	payload := map[string]interface{}{"DiagnosticDataType": dataType}
+	if oemType != "" {
+		payload["OEMDiagnosticDataType"] = oemType
+	}
+
+	cmd.PrintErrf("Collecting %s diagnostic data from %s...\n", dataType, info.Service.ODataID)
+	resp, err := info.Service.GetClient().Post(action.Target, payload)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to start diagnostic data collection: %v", err)
+	}
+
+	wait, _ := cmd.Flags().GetDuration("wait")
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	result, err := utils.WaitForTask(ctx, info.Service.GetClient(), resp, interval, func(task *utils.TaskStatus) {
+		cmd.PrintErrf("Task %s: %s (%d%%)\n", task.ODataID, task.TaskState, task.PercentComplete)
+	})
+	if err != nil {
+		return utils.ErrorExit(cmd, "diagnostic data collection failed: %v", err)
+	}
+
+	entry, err := diagnosticEntry(info.Service, result, existing)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	output, size, err := saveDiagnosticData(info.Service.GetClient(), entry, output)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to save diagnostic data: %v", err)
+	}
+
+	cmd.Printf("Saved %d bytes of diagnostic data to %s\n", size, output)
+	return nil
+}
+
+// findDiagnosticService locates the log service to collect diagnostic data
+// from. If no service is named, the first one supporting the data type is
+// used, preferring manager logs for manager data and system logs otherwise.
+func findDiagnosticService(service *gofish.Service, name, dataType string) (*utils.LogServiceInfo, *utils.DiagnosticDataAction, error) {
+	if name != "" {
+		info, err := utils.FindLogService(service, name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		action, err := utils.CollectDiagnosticDataAction(info.Service)
+		if err != nil {
+			return nil, nil, err
+		}
+		if action == nil {
+			return nil, nil, utils.Error("log service '%s' does not support collecting diagnostic data", name)
+		}
+
+		return info, action, nil
+	}
+
+	services, err := utils.LogServices(service)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	preferred := "System "
+	if dataType == string(redfish.ManagerLogDiagnosticDataTypes) {
+		preferred = "Manager "
+	}
+
+	var found *utils.LogServiceInfo
+	var foundAction *utils.DiagnosticDataAction
+	for i := range services {
+		action, err := utils.CollectDiagnosticDataAction(services[i].Service)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if action == nil || (len(action.AllowedTypes) > 0 && !slices.Contains(action.AllowedTypes, dataType)) {
+			continue
+		}
+
+		if strings.HasPrefix(services[i].Source, preferred) {
+			return &services[i], action, nil
+		}
+
+		if found == nil {
+			found = &services[i]
+			foundAction = action
+		}
+	}
+
+	if found == nil {
+		return nil, nil, utils.Error("no log service supports collecting %s diagnostic data", dataType)
+	}
+
+	return found, foundAction, nil
+}
+
+// diagnosticEntry finds the log entry holding the collected data. Services
+// either return the entry location when the task completes, return the entry
+// itself, or only add it to the log.
+func diagnosticEntry(logService *redfish.LogService, result *utils.TaskResult, existing []*redfish.LogEntry) (*redfish.LogEntry, error) {
+	c := logService.GetClient()
+	if result.Location != "" && strings.Contains(result.Location, "/LogServices/") {
+		return redfish.GetLogEntry(c, result.Location)
+	}
+
+	entry := &redfish.LogEntry{}
+	if json.Unmarshal(result.Body, entry) == nil && (entry.AdditionalDataURI != "" || entry.DiagnosticData != "") {
+		return entry, nil
+	}
+
+	entries, err := utils.LogEntries(logService)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look for the newest entry that was not there before
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry = entries[i]
+		if entry.AdditionalDataURI == "" && entry.DiagnosticData == "" {
+			continue
+		}
+
+		if !slices.ContainsFunc(existing, func(e *redfish.LogEntry) bool { return e.ODataID == entry.ODataID }) {
+			return entry, nil
+		}
+	}
+
+	return nil, utils.Error("unable to find the collected diagnostic data in log service '%s'", logService.ID)
+}
+
+// saveDiagnosticData writes the data of a diagnostic log entry to a file,
+// returning the name of the file and the number of bytes written.
+func saveDiagnosticData(c common.Client, entry *redfish.LogEntry, output string) (string, int64, error) {
+	if entry.AdditionalDataURI == "" {
+		data, err := base64.StdEncoding.DecodeString(entry.DiagnosticData)
+		if err != nil {
+			return "", 0, err
+		}
+
+		if output == "" {
+			output = fmt.Sprintf("%s-%s.bin", entry.ID, strings.ToLower(string(entry.DiagnosticDataType)))
+		}
+		return output, int64(len(data)), os.WriteFile(output, data, 0o600)
+	}
+
+	resp, err := c.Get(entry.AdditionalDataURI)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	if output == "" {
+		output = path.Base(entry.AdditionalDataURI)
+		_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+		if err == nil && params["filename"] != "" {
+			output = path.Base(params["filename"])
+		}
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	size, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return output, size, file.Close()
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package collect
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var logsCmd = &cobra.Command{
+	Use:     "logs",
+	Aliases: []string{"log"},
+	Short:   "Bundle all log entries into an archive.",
+	Long: "Retrieves the entries of every log service of the managers, systems and chassis and " +
+		"saves them in a tar.gz archive, along with a manifest describing its contents.",
+	RunE: collectLogs,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	logsCmd.Flags().StringP("output", "o", "", "File to save the bundle to. Defaults to ctlfish-logs-TIMESTAMP.tar.gz.")
+	logsCmd.Flags().SortFlags = true
+}
+
+// bundleManifest describes the contents of a log bundle.
+type bundleManifest struct {
+	Created        string
+	Vendor         string
+	Product        string
+	RedfishVersion string
+	UUID           string
+	LogServices    []bundleLogService
+}
+
+// bundleLogService describes a single log service in a log bundle.
+type bundleLogService struct {
+	Source  string
+	ID      string
+	Name    string
+	URI     string
+	File    string `json:",omitempty"`
+	Entries int
+	Error   string `json:",omitempty"`
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// collectLogs writes the entries of all log services to a tar.gz archive.
+func collectLogs(cmd *cobra.Command, _ []string) error {
+	now := time.Now()
+	output, _ := cmd.Flags().GetString("output")
+	if output == "" {
+		output = fmt.Sprintf("ctlfish-logs-%s.tar.gz", now.Format("20060102-150405"))
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	services, err := utils.LogServices(c.Service)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return utils.ErrorExit(cmd, "unable to create bundle: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	archive := tar.NewWriter(gz)
+
+	manifest := bundleManifest{
+		Created:        now.Format(time.RFC3339),
+		Vendor:         c.Service.Vendor,
+		Product:        c.Service.Product,
+		RedfishVersion: c.Service.RedfishVersion,
+		UUID:           c.Service.UUID,
+		LogServices:    []bundleLogService{},
+	}
+
+	// Failing to read one log should not prevent collecting the others, so
+	// errors are recorded in the manifest instead
+	total := 0
+	for _, info := range services {
+		logService := info.Service
+		entry := bundleLogService{
+			Source: info.Source,
+			ID:     logService.ID,
+			Name:   logService.Name,
+			URI:    logService.ODataID,
+		}
+
+		entries, err := utils.LogEntries(logService)
+		if err != nil {
+			entry.Error = err.Error()
+			cmd.PrintErrf("Unable to read %s: %v\n", logService.ODataID, err)
+		} else {
+			entry.File = "logs/" + unsafeFileChars.ReplaceAllString(info.Source+"-"+logService.ID, "_") + ".json"
+			entry.Entries = len(entries)
+			total += len(entries)
+
+			err = addBundleFile(archive, entry.File, entries, now)
+			if err != nil {
+				return utils.ErrorExit(cmd, "failed to write bundle: %v", err)
+			}
+		}
+
+		manifest.LogServices = append(manifest.LogServices, entry)
+	}
+
+	err = addBundleFile(archive, "manifest.json", manifest, now)
+	if err == nil {
+		err = archive.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to write bundle: %v", err)
+	}
+
+	cmd.Printf("Saved %d entries from %d log services to %s\n", total, len(services), output)
+	return nil
+}
+
+// addBundleFile adds the JSON representation of a value to the archive.
+func addBundleFile(archive *tar.Writer, name string, value interface{}, modified time.Time) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = archive.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: modified,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = archive.Write(data)
+	return err
+}
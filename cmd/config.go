@@ -31,6 +31,7 @@ func init() {
 	configCmd.AddCommand(NewAddConfigCmd())
 	configCmd.AddCommand(NewRemoveConfigCmd())
 	configCmd.AddCommand(NewSetConfigCmd())
+	configCmd.AddCommand(NewGroupConfigCmd())
 	rootCmd.AddCommand(configCmd)
 }
 
@@ -100,6 +101,8 @@ func NewAddConfigCmd() *cobra.Command {
 	cmd.Flags().StringVar(&systemSettings.Protocol, "protocol", "https", "Protocol to use (https (default) or http).")
 	cmd.Flags().BoolVar(&systemSettings.Secure, "secure", false, "Enforce certificate validation with https connections (default allows self-signed certs).")
 	cmd.Flags().BoolVar(&makeDefault, "default", false, "Set this connection as the default.")
+	addLimitFlags(cmd, &systemSettings)
+	addNetworkFlags(cmd, &systemSettings)
 
 	_ = cmd.MarkFlagRequired("username")
 	_ = cmd.MarkFlagRequired("password")
@@ -152,6 +155,12 @@ func addNewSystem(cmd *cobra.Command, name string, settings *config.SystemConfig
 		}
 	}
 
+	if settings.Proxy != "" {
+		if _, err := utils.ProxyURL(settings.Proxy); err != nil {
+			return utils.ErrorExit(cmd, "%v", err)
+		}
+	}
+
 	// Add the new connection. We don't validate user name and password here. It
 	// will be handled when they actually try to perform an operation.
 	err := config.AddSystemConfig(name, settings, makeDefault)
@@ -218,8 +227,30 @@ func NewSetConfigCmd() *cobra.Command {
 					system.Secure = systemSettings.Secure
 				case "default":
 					defaultConnection = makeDefault
+				case "timeout":
+					system.Timeout = systemSettings.Timeout
+				case "connect-timeout":
+					system.ConnectTimeout = systemSettings.ConnectTimeout
+				case "retries":
+					system.Retries = systemSettings.Retries
+				case "rate-limit":
+					system.RateLimit = systemSettings.RateLimit
+				case "proxy":
+					system.Proxy = systemSettings.Proxy
+				case "jump-host":
+					system.JumpHost = systemSettings.JumpHost
+				case "jump-user":
+					system.JumpUser = systemSettings.JumpUser
+				case "jump-key":
+					system.JumpKey = systemSettings.JumpKey
 				}
 			})
+			if system.Proxy != "" {
+				if _, err := utils.ProxyURL(system.Proxy); err != nil {
+					return utils.ErrorExit(cmd, "%v", err)
+				}
+			}
+
 			err := config.AddSystemConfig(args[0], system, defaultConnection)
 			if err != nil {
 				return utils.ErrorExit(cmd, "error adding system: %v", err)
@@ -237,8 +268,91 @@ func NewSetConfigCmd() *cobra.Command {
 	cmd.Flags().StringVar(&systemSettings.Protocol, "protocol", "https", "Protocol to use (https (default) or http).")
 	cmd.Flags().BoolVar(&systemSettings.Secure, "secure", false, "Enforce certificate validation with https connections (default allows self-signed certs).")
 	cmd.Flags().BoolVar(&makeDefault, "default", false, "Set this connection as the default.")
+	addLimitFlags(cmd, &systemSettings)
+	addNetworkFlags(cmd, &systemSettings)
 
 	cmd.Flags().SortFlags = true
 
 	return cmd
 }
+
+// NewGroupConfigCmd returns a command for managing groups of connections.
+func NewGroupConfigCmd() *cobra.Command {
+	remove := false
+	cmd := &cobra.Command{
+		Use:   "group NAME [CONNECTION_NAME...]",
+		Short: "Set or show a group of connections.",
+		Long: dedent.Dedent(`Set or show a group of connections.
+
+		Groups can be given instead of a connection to commands that work on
+		several systems at once, such as health. Given connection names, the
+		group is created or replaced with them. Otherwise the connections in
+		the group are shown.`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if remove {
+				err := config.RemoveGroup(name)
+				if err != nil {
+					return utils.ErrorExit(cmd, "error removing group: %v", err)
+				}
+				return nil
+			}
+
+			if len(args) > 1 {
+				err := config.SetGroup(name, args[1:])
+				if err != nil {
+					return utils.ErrorExit(cmd, "error setting group: %v", err)
+				}
+			}
+
+			members := config.GetGroup(name)
+			if members == nil {
+				return utils.ErrorExit(cmd, "group '%s' was not found.", name)
+			}
+
+			writer := utils.NewTableWriter(cmd.OutOrStdout(), "name", "user", "endpoint")
+			for _, member := range members {
+				system := config.GetSystem(member)
+				if system == nil {
+					writer.AddRow(member, "", "(not found)")
+					continue
+				}
+				writer.AddRow(member, system.Username,
+					fmt.Sprintf("%s://%s:%d", system.Protocol, system.Host, system.Port))
+			}
+			writer.Render()
+			return nil
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	cmd.Flags().BoolVar(&remove, "remove", false, "Remove the group. The connections in it are kept.")
+
+	return cmd
+}
+
+// addLimitFlags adds the flags for the timeouts, retries and rate limit of a
+// connection. Retries are only saved if the flag is given, so connections
+// without the setting follow the default.
+func addLimitFlags(cmd *cobra.Command, settings *config.SystemConfig) {
+	retries := 0
+	cmd.Flags().DurationVar(&settings.Timeout, "timeout", 0, "Time allowed for each request (default 2m).")
+	cmd.Flags().DurationVar(&settings.ConnectTimeout, "connect-timeout", 0, "Time allowed to connect (default 30s).")
+	cmd.Flags().IntVar(&retries, "retries", 0, "Times to retry failed requests (default 2).")
+	cmd.Flags().Float64Var(&settings.RateLimit, "rate-limit", 0, "Most requests to send per second (default no limit).")
+
+	cmd.PreRun = func(cmd *cobra.Command, _ []string) {
+		if cmd.Flags().Changed("retries") {
+			settings.Retries = &retries
+		}
+	}
+}
+
+// addNetworkFlags adds the flags for reaching a connection through a proxy or
+// an SSH jump host.
+func addNetworkFlags(cmd *cobra.Command, settings *config.SystemConfig) {
+	cmd.Flags().StringVar(&settings.Proxy, "proxy", "", "URL of an HTTP, HTTPS or SOCKS5 proxy to connect through, such as socks5://host:1080.")
+	cmd.Flags().StringVar(&settings.JumpHost, "jump-host", "", "SSH server to connect through, as HOST or HOST:PORT.")
+	cmd.Flags().StringVar(&settings.JumpUser, "jump-user", "", "User to log in to the jump host as (default the current user).")
+	cmd.Flags().StringVar(&settings.JumpKey, "jump-key", "", "Private key file for the jump host (default uses the SSH agent).")
+}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package create
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create objects.",
+	}
+
+	createCmd.AddCommand(subscriptionCmd)
+
+	return createCmd
+}
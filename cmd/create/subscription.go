@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package create
+
+import (
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var subscriptionCmd = &cobra.Command{
+	Use:     "subscription",
+	Aliases: []string{"sub"},
+	Short:   "Create an event subscription.",
+	Long: dedent.Dedent(`Create an event subscription so the service pushes events to a destination.
+
+	Events may be filtered by registry prefix and resource type. Older services
+	only support filtering by event type, which is deprecated in newer versions
+	of Redfish.`),
+	RunE: createSubscription,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	subscriptionCmd.Flags().StringP("destination", "d", "", "The URL events are sent to.")
+	subscriptionCmd.Flags().String("context", "", "A string sent with each event to identify the subscription.")
+	subscriptionCmd.Flags().StringSlice("event-types", []string{}, "Only send events of these types (Alert, StatusChange, ...).")
+	subscriptionCmd.Flags().StringSlice("registry-prefixes", []string{}, "Only send events with messages from these registries (Base, ResourceEvent, ...).")
+	subscriptionCmd.Flags().StringSlice("resource-types", []string{}, "Only send events for these resource types (Chassis, Power, ...).")
+	subscriptionCmd.Flags().StringArray("header", []string{}, "HTTP header to send with each event as 'Name: value'. May be repeated.")
+	subscriptionCmd.Flags().SortFlags = true
+
+	_ = subscriptionCmd.MarkFlagRequired("destination")
+}
+
+// createSubscription creates a new event subscription.
+func createSubscription(cmd *cobra.Command, _ []string) error {
+	subscription := &utils.Subscription{}
+	subscription.Destination, _ = cmd.Flags().GetString("destination")
+	subscription.Context, _ = cmd.Flags().GetString("context")
+	subscription.EventTypes, _ = cmd.Flags().GetStringSlice("event-types")
+	subscription.RegistryPrefixes, _ = cmd.Flags().GetStringSlice("registry-prefixes")
+	subscription.ResourceTypes, _ = cmd.Flags().GetStringSlice("resource-types")
+
+	headers, _ := cmd.Flags().GetStringArray("header")
+	for _, header := range headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return utils.ErrorExit(cmd, "invalid header '%s', must be 'Name: value'", header)
+		}
+		subscription.HTTPHeaders = append(
+			subscription.HTTPHeaders, map[string]string{strings.TrimSpace(name): strings.TrimSpace(value)})
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	eventService, err := c.Service.EventService()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to access event service: %v", err)
+	}
+
+	uri, err := utils.CreateSubscription(eventService, subscription)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to create subscription: %v", err)
+	}
+
+	cmd.Printf("Created subscription %s\n", uri)
+	return nil
+}
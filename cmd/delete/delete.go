@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package deletecmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	deleteCmd := &cobra.Command{
+		Use:     "delete",
+		Aliases: []string{"del", "rm"},
+		Short:   "Delete objects.",
+	}
+
+	deleteCmd.AddCommand(subscriptionCmd)
+
+	return deleteCmd
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package deletecmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var subscriptionCmd = &cobra.Command{
+	Use:     "subscription ID...",
+	Aliases: []string{"subscriptions", "sub"},
+	Short:   "Delete event subscriptions.",
+	Long:    "Deletes one or more event subscriptions by ID or URI.",
+	RunE:    deleteSubscription,
+	Args:    cobra.MinimumNArgs(1),
+}
+
+// deleteSubscription removes the requested event subscriptions.
+func deleteSubscription(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	eventService, err := c.Service.EventService()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to access event service: %v", err)
+	}
+
+	subscriptions, err := eventService.GetEventSubscriptions()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve subscriptions: %v", err)
+	}
+
+	// Make sure everything requested exists before deleting anything
+	targets := []*redfish.EventDestination{}
+	for _, arg := range args {
+		var found *redfish.EventDestination
+		for _, subscription := range subscriptions {
+			if subscription.ID == arg || subscription.ODataID == arg {
+				found = subscription
+				break
+			}
+		}
+
+		if found == nil {
+			return utils.ErrorExit(cmd, "subscription '%s' was not found.", arg)
+		}
+		targets = append(targets, found)
+	}
+
+	for _, subscription := range targets {
+		err = eventService.DeleteEventSubscription(subscription.ODataID)
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to delete subscription '%s': %v", subscription.ID, err)
+		}
+
+		cmd.Printf("Deleted subscription %s\n", subscription.ODataID)
+	}
+
+	return nil
+}
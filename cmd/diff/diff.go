@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/settings"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Cmd gets the diff command.
+func Cmd() *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff [CONNECTION_OR_GROUP...]",
+		Short: "Compare settings against a baseline or between connections.",
+		Long: dedent.Dedent(fmt.Sprintf(`Compare the settings of connections against a baseline, or of two
+		connections with each other.
+
+		With --baseline, the settings of the default connection or the given
+		connections and groups are checked against the baseline file. Without
+		it, the settings of the two given connections are compared side by
+		side.
+
+		The baseline is a YAML file with any of these sections:
+
+		  %s
+
+		Each section holds the Redfish properties to check, for example:
+
+		  bios:
+		    BootMode: Uefi
+		  ntp:
+		    ProtocolEnabled: true
+		    NTPServers: [0.pool.ntp.org, 1.pool.ntp.org]
+		  network_protocols:
+		    IPMI: {ProtocolEnabled: false}
+		  users:
+		    admin: {RoleId: Administrator, Enabled: true}
+		  firmware:
+		    BIOS: P79 v1.45 (12/06/2017)
+
+		Only the settings listed in the baseline are checked, except for users,
+		where any user that is not listed is also reported. Listed users are
+		only checked for the properties given.
+
+		The exit code is 0 if nothing differs, 1 if there are differences, 2
+		if the settings of a connection could not be read and 3 if the
+		comparison could not be made, such as when the baseline is invalid.`, strings.Join(settings.Sections(), ", "))),
+		RunE: diffSettings,
+	}
+
+	diffCmd.Flags().String("baseline", "", "Baseline `FILE` to check the settings against.")
+	diffCmd.Flags().SortFlags = true
+	utils.MarkMonitoring(diffCmd)
+
+	return diffCmd
+}
+
+// diffSettings compares against the baseline, or between two connections.
+func diffSettings(cmd *cobra.Command, args []string) error {
+	baselineFile, _ := cmd.Flags().GetString("baseline")
+	if baselineFile == "" {
+		return compareConnections(cmd, args)
+	}
+
+	baseline, err := settings.Load(baselineFile)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	connections, err := utils.Connections(args)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	results := make([]*result, len(connections))
+	utils.ForEachConnection(connections, func(i int, connection string) {
+		results[i] = &result{connection: connection}
+		results[i].current, results[i].err = readSettings(connection, baseline.Names()...)
+		if results[i].current != nil {
+			results[i].differences = settings.Compare(readable(baseline, results[i].err), results[i].current, true)
+		}
+	})
+
+	headers := []string{"setting", "baseline", "actual"}
+	if len(connections) > 1 {
+		headers = append([]string{"connection"}, headers...)
+	}
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), headers...)
+
+	worst := utils.SeverityOK
+	drifted := 0
+	for _, r := range results {
+		if r.err != nil {
+			cmd.PrintErrf("Unable to check %s: %v\n", utils.DescribeConnection(r.connection), r.err)
+			worst = utils.SeverityCritical
+		}
+		if len(r.differences) > 0 {
+			drifted++
+			worst = utils.MaxSeverity(worst, utils.SeverityWarning)
+		}
+
+		for _, d := range r.differences {
+			row := []interface{}{d.Path, settings.FormatValue(d.Left), settings.FormatValue(d.Right)}
+			if len(connections) > 1 {
+				row = append([]interface{}{utils.DescribeConnection(r.connection)}, row...)
+			}
+			writer.AddRow(row...)
+		}
+	}
+
+	if writer.RowCount() > 0 {
+		writer.Render()
+	}
+
+	switch {
+	case drifted > 0:
+		cmd.Printf("%d of %d connections differ from the baseline.\n", drifted, len(connections))
+	case worst == utils.SeverityOK:
+		cmd.Printf("All %d connections match the baseline.\n", len(connections))
+	}
+
+	return utils.SeverityExit(cmd, worst)
+}
+
+// compareConnections compares all settings of two connections.
+func compareConnections(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return utils.ErrorExit(cmd, "two connections are needed to compare, or a --baseline to check against")
+	}
+
+	results := make([]*result, 2)
+	utils.ForEachConnection(args, func(i int, connection string) {
+		results[i] = &result{connection: connection}
+		results[i].current, results[i].err = readSettings(connection)
+	})
+
+	for _, r := range results {
+		if r.err != nil {
+			cmd.PrintErrf("Unable to read the settings of %s: %v\n", r.connection, r.err)
+			return utils.SeverityExit(cmd, utils.SeverityCritical)
+		}
+	}
+
+	differences := settings.Compare(results[0].current, results[1].current, false)
+	if len(differences) == 0 {
+		cmd.Printf("The settings of %s and %s are the same.\n", args[0], args[1])
+		return nil
+	}
+
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), "setting", args[0], args[1])
+	for _, d := range differences {
+		writer.AddRow(d.Path, settings.FormatValue(d.Left), settings.FormatValue(d.Right))
+	}
+	writer.Render()
+	cmd.Printf("%d settings differ.\n", len(differences))
+
+	return utils.SeverityExit(cmd, utils.SeverityWarning)
+}
+
+// result is the comparison of one connection.
+type result struct {
+	connection  string
+	current     settings.Settings
+	differences []settings.Difference
+	err         error
+}
+
+// readSettings reads the named sections of a connection's settings. Sections
+// that could not be read are reported as an error, and the rest are still
+// compared.
+func readSettings(connection string, sections ...string) (settings.Settings, error) {
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout()
+
+	return settings.Read(c, sections...)
+}
+
+// readable leaves out the sections that could not be read, so they are not
+// reported as missing.
+func readable(baseline settings.Settings, err error) settings.Settings {
+	var readErr *settings.ReadError
+	if !errors.As(err, &readErr) {
+		return baseline
+	}
+
+	result := settings.Settings{}
+	for name, value := range baseline {
+		if _, failed := readErr.Sections[name]; !failed {
+			result[name] = value
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package diff
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestDiffBaseline(t *testing.T) {
+	mockuptest.Start(t)
+	baseline := mockuptest.WriteFile(t, "baseline.yaml", `
+bios:
+  BootMode: Uefi
+ntp:
+  NTPServers: [0.pool.ntp.org, 1.pool.ntp.org]
+account_policy:
+  MinPasswordLength: 8
+users:
+  admin: {RoleId: Administrator, Enabled: true}
+  operator: {RoleId: Operator, Enabled: true}
+firmware:
+  BIOS: P79 v1.45 (12/06/2017)
+`)
+
+	output, err := mockuptest.Run(t, Cmd(), "--baseline", baseline)
+	if err != nil {
+		t.Fatalf("expected no drift, got %v:\n%s", err, output)
+	}
+	if !strings.Contains(output, "All 1 connections match the baseline.") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestDiffDrift(t *testing.T) {
+	server := mockuptest.Start(t)
+	baseline := mockuptest.WriteFile(t, "baseline.yaml", `
+bios:
+  ProcVirtualization: Enabled
+network_protocols:
+  IPMI: {ProtocolEnabled: false}
+users:
+  admin: {RoleId: Administrator}
+`)
+
+	err := server.Update("/redfish/v1/Systems/1/Bios", map[string]interface{}{
+		"Attributes": map[string]interface{}{"ProcVirtualization": "Disabled"},
+	})
+	if err != nil {
+		t.Fatalf("unable to change the BIOS: %v", err)
+	}
+
+	output, err := mockuptest.Run(t, Cmd(), "--baseline", baseline)
+	var exitErr *utils.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Fatalf("expected exit code 1 for drift, got %v:\n%s", err, output)
+	}
+
+	for _, expected := range []string{
+		"bios.ProcVirtualization  Enabled",
+		"Disabled",
+		"users.operator",
+		"1 of 1 connections differ from the baseline.",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+	// The properties of the admin user that are not listed are not checked
+	if strings.Contains(output, "IPMI") || strings.Contains(output, "users.admin") {
+		t.Errorf("settings that match should not be listed:\n%s", output)
+	}
+}
+
+func TestDiffConnections(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "mockup", "mockup")
+	if err != nil || !strings.Contains(output, "The settings of mockup and mockup are the same.") {
+		t.Errorf("expected no differences, got %v:\n%s", err, output)
+	}
+
+	_, err = mockuptest.Run(t, Cmd(), "mockup")
+	if err == nil || !strings.Contains(err.Error(), "two connections are needed") {
+		t.Errorf("expected an error for a single connection, got: %v", err)
+	}
+}
+
+func TestDiffInvalidBaseline(t *testing.T) {
+	mockuptest.Start(t)
+	baseline := mockuptest.WriteFile(t, "baseline.yaml", "bois:\n  BootMode: Uefi\n")
+
+	cmd := Cmd()
+	_, err := mockuptest.Run(t, cmd, "--baseline", baseline)
+	if err == nil || !strings.Contains(err.Error(), "unknown section 'bois'") {
+		t.Errorf("expected unknown section error, got: %v", err)
+	}
+
+	if code := utils.ExitCode(cmd, err); code != utils.ExitUnknown {
+		t.Errorf("expected the unknown exit code, got %d", code)
+	}
+}
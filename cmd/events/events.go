@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package events
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	eventsCmd := &cobra.Command{
+		Use:     "events",
+		Aliases: []string{"event"},
+		Short:   "Work with Redfish events.",
+	}
+
+	eventsCmd.AddCommand(listenCmd)
+
+	return eventsCmd
+}
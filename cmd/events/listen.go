@@ -0,0 +1,291 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var listenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Print events as the service sends them.",
+	Long: dedent.Dedent(`Print events as the service sends them, until interrupted.
+
+	In sse mode, the service's ServerSentEventUri stream is read. In webhook mode,
+	an HTTPS endpoint is started to receive pushed events. With --register, a
+	subscription pointing at the endpoint is created and removed again on exit.
+
+	Messages are resolved from the message registries when the service does not
+	include the message text.`),
+	RunE: listenEvents,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	listenCmd.Flags().String("mode", "sse", "How to receive events (sse, webhook).")
+	listenCmd.Flags().String("listen", ":8443", "Address to listen on in webhook mode.")
+	listenCmd.Flags().String("cert", "", "TLS certificate file for the webhook. A self-signed certificate is generated if not set.")
+	listenCmd.Flags().String("key", "", "TLS key file for the webhook certificate.")
+	listenCmd.Flags().Bool("register", false, "Create a subscription for the webhook and remove it on exit.")
+	listenCmd.Flags().String("destination", "", "The webhook URL given to the service with --register. Detected if not set.")
+	listenCmd.Flags().String("context", "ctlfish", "The context of the subscription created with --register.")
+	listenCmd.Flags().StringSlice("registry-prefixes", []string{}, "Only show events with messages from these registries.")
+	listenCmd.Flags().StringP("output", "o", "table", "Output format (table, json).")
+	listenCmd.Flags().SortFlags = true
+
+	listenCmd.MarkFlagsRequiredTogether("cert", "key")
+}
+
+// listenEvents receives events and prints them until interrupted.
+func listenEvents(cmd *cobra.Command, _ []string) error {
+	mode, _ := cmd.Flags().GetString("mode")
+	if mode != "sse" && mode != "webhook" {
+		return utils.ErrorExit(cmd, "invalid mode '%s', must be sse or webhook", mode)
+	}
+
+	output, _ := cmd.Flags().GetString("output")
+	if output != "table" && output != "json" {
+		return utils.ErrorExit(cmd, "invalid output '%s', must be table or json", output)
+	}
+
+	printer := &eventPrinter{out: cmd.OutOrStdout(), json: output == "json"}
+	printer.prefixes, _ = cmd.Flags().GetStringSlice("registry-prefixes")
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+	printer.messages = utils.NewMessageRegistries(c)
+
+	eventService, err := c.Service.EventService()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to access event service: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	payloads := make(chan []byte)
+	errs := make(chan error, 1)
+	if mode == "sse" {
+		if eventService.ServerSentEventURI == "" {
+			return utils.ErrorExit(cmd, "the event service does not support server-sent events")
+		}
+
+		cmd.PrintErrf("Reading events from %s, press Ctrl+C to stop.\n", eventService.ServerSentEventURI)
+		go func() {
+			errs <- streamEvents(ctx, eventService, payloads)
+		}()
+	} else {
+		cleanup, err := startWebhook(ctx, cmd, connection, eventService, payloads, errs)
+		if err != nil {
+			return utils.ErrorExit(cmd, "%v", err)
+		}
+		defer cleanup()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return utils.ErrorExit(cmd, "%v", err)
+		case payload := <-payloads:
+			err := printer.print(payload)
+			if err != nil {
+				cmd.PrintErrln("Error:", err)
+			}
+		}
+	}
+}
+
+// startWebhook starts an HTTPS server that receives events, registering it
+// with the event service if requested. The returned function stops the server
+// and removes the subscription.
+func startWebhook(
+	ctx context.Context, cmd *cobra.Command, connection string,
+	eventService *redfish.EventService, payloads chan<- []byte, errs chan<- error,
+) (func(), error) {
+	address, _ := cmd.Flags().GetString("listen")
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, utils.Error("invalid listen address '%s': %v", address, err)
+	}
+
+	register, _ := cmd.Flags().GetBool("register")
+	destination, _ := cmd.Flags().GetString("destination")
+	localIP := ""
+	if register && destination == "" {
+		settings, err := utils.SystemSettings(connection)
+		if err != nil {
+			return nil, err
+		}
+
+		localIP, err = utils.LocalAddress(settings.Host, settings.Port)
+		if err != nil {
+			return nil, utils.Error("unable to determine the address to register, use --destination: %v", err)
+		}
+		destination = fmt.Sprintf("https://%s/", net.JoinHostPort(localIP, port))
+	}
+
+	certificate, err := webhookCertificate(cmd, localIP)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           webhookHandler(ctx, payloads),
+		TLSConfig:         &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, utils.Error("unable to listen on '%s': %v", address, err)
+	}
+
+	go func() {
+		err := server.ServeTLS(listener, "", "")
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+	cmd.PrintErrf("Listening for events on %s, press Ctrl+C to stop.\n", listener.Addr())
+
+	cleanup := func() {
+		_ = server.Close()
+	}
+
+	if !register {
+		return cleanup, nil
+	}
+
+	subscriptionContext, _ := cmd.Flags().GetString("context")
+	prefixes, _ := cmd.Flags().GetStringSlice("registry-prefixes")
+	uri, err := utils.CreateSubscription(eventService, &utils.Subscription{
+		Destination:      destination,
+		Context:          subscriptionContext,
+		RegistryPrefixes: prefixes,
+	})
+	if err != nil {
+		cleanup()
+		return nil, utils.Error("failed to create subscription: %v", err)
+	}
+	cmd.PrintErrf("Registered subscription %s for %s\n", uri, destination)
+
+	return func() {
+		err := eventService.DeleteEventSubscription(uri)
+		if err != nil {
+			cmd.PrintErrf("Failed to remove subscription %s: %v\n", uri, utils.ErrorMessage(err))
+		} else {
+			cmd.PrintErrf("Removed subscription %s\n", uri)
+		}
+		cleanup()
+	}, nil
+}
+
+// webhookCertificate loads the certificate for the webhook, or generates one.
+func webhookCertificate(cmd *cobra.Command, hosts ...string) (tls.Certificate, error) {
+	certFile, _ := cmd.Flags().GetString("cert")
+	keyFile, _ := cmd.Flags().GetString("key")
+	if certFile != "" {
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+
+	hostname, _ := os.Hostname()
+	hosts = append(hosts, hostname, "localhost", "127.0.0.1")
+	return utils.SelfSignedCertificate(hosts...)
+}
+
+// webhookHandler accepts events posted by the service.
+func webhookHandler(ctx context.Context, payloads chan<- []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case payloads <- payload:
+		case <-ctx.Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// streamEvents reads the server-sent event stream of the event service.
+func streamEvents(ctx context.Context, eventService *redfish.EventService, payloads chan<- []byte) error {
+	resp, err := eventService.GetClient().GetWithHeaders(
+		eventService.ServerSentEventURI, map[string]string{"Accept": "text/event-stream"})
+	if err != nil {
+		return utils.Error("unable to open the event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Closing the body is the only way to interrupt a blocked read
+	go func() {
+		<-ctx.Done()
+		resp.Body.Close()
+	}()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line ends the event
+			if len(data) > 0 {
+				select {
+				case payloads <- []byte(strings.Join(data, "\n")):
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			data = data[:0]
+			continue
+		}
+
+		if value, found := strings.CutPrefix(line, "data:"); found {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return utils.Error("event stream failed: %v", err)
+	}
+
+	return utils.Error("the service closed the event stream")
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+// event is a ResourceCreated event without its message, which is resolved
+// from the registries.
+var event = map[string]interface{}{
+	"@odata.type": "#Event.v1_7_0.Event",
+	"Id":          "1",
+	"Name":        "Event",
+	"Context":     "ctlfish-test",
+	"Events": []map[string]interface{}{{
+		"EventId":           "42",
+		"EventTimestamp":    "2026-10-19T08:30:00Z",
+		"MessageId":         "ResourceEvent.1.3.ResourceCreated",
+		"OriginOfCondition": map[string]interface{}{"@odata.id": "/redfish/v1/Systems/1"},
+	}},
+}
+
+// listenContext makes the listen command run until the returned function is
+// called, or the test times out.
+func listenContext(t *testing.T) context.CancelFunc {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	listenCmd.SetContext(ctx)
+	t.Cleanup(func() { listenCmd.SetContext(context.Background()) })
+
+	return cancel
+}
+
+// waitFor checks a condition until it is met, returning false if it is not
+// met in time.
+func waitFor(condition func() bool) bool {
+	for i := 0; i < 500; i++ {
+		if condition() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+// checkEvent checks the output of the listen command has the event as JSON.
+func checkEvent(t *testing.T, output string) {
+	t.Helper()
+
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		printed := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &printed); err != nil {
+			t.Fatalf("the event is not JSON: %v\n%s", err, line)
+		}
+
+		for key, expected := range map[string]string{
+			"EventId":   "42",
+			"MessageId": "ResourceEvent.1.3.ResourceCreated",
+			"Message":   "The resource has been created successfully.",
+			"Severity":  "OK",
+			"Origin":    "/redfish/v1/Systems/1",
+			"Context":   "ctlfish-test",
+		} {
+			if printed[key] != expected {
+				t.Errorf("expected %s to be %q, got %v", key, expected, printed[key])
+			}
+		}
+		return
+	}
+
+	t.Errorf("no event was printed:\n%s", output)
+}
+
+func TestListenWebhook(t *testing.T) {
+	server := mockuptest.Start(t)
+	cancel := listenContext(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	destination := fmt.Sprintf("https://%s/", address)
+
+	subscription := "/redfish/v1/EventService/Subscriptions/2"
+	posted := make(chan error, 1)
+	go func() {
+		defer cancel()
+
+		// The subscription is created once the webhook is listening
+		if !waitFor(func() bool {
+			resource, found := server.Resource(subscription)
+			return found && resource["Destination"] == destination
+		}) {
+			posted <- fmt.Errorf("the subscription was not created")
+			return
+		}
+
+		body, _ := json.Marshal(event)
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // self-signed test certificate
+		}}
+		resp, err := client.Post(destination, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNoContent {
+				err = fmt.Errorf("unexpected status %s", resp.Status)
+			}
+		}
+		posted <- err
+	}()
+
+	output, err := mockuptest.Run(t, Cmd(),
+		"listen", "--mode", "webhook", "--listen", address, "--register", "--destination", destination, "-o", "json")
+	if err != nil {
+		t.Fatalf("listen failed: %v\n%s", err, output)
+	}
+	if err := <-posted; err != nil {
+		t.Fatalf("unable to post the event: %v\n%s", err, output)
+	}
+
+	checkEvent(t, output)
+	for _, expected := range []string{"Registered subscription " + subscription, "Removed subscription " + subscription} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+	if _, found := server.Resource(subscription); found {
+		t.Error("expected the subscription to be removed")
+	}
+}
+
+func TestListenSSE(t *testing.T) {
+	server := mockuptest.Start(t)
+	cancel := listenContext(t)
+
+	sent := make(chan bool, 1)
+	go func() {
+		defer cancel()
+
+		// The event can only be sent once the stream is open
+		delivered := waitFor(func() bool {
+			count, err := server.SendEvent(event)
+			return err == nil && count > 0
+		})
+		if delivered {
+			// Give the event time to be printed before stopping
+			time.Sleep(250 * time.Millisecond)
+		}
+		sent <- delivered
+	}()
+
+	output, err := mockuptest.Run(t, Cmd(), "listen", "-o", "json")
+	if err != nil {
+		t.Fatalf("listen failed: %v\n%s", err, output)
+	}
+	if !<-sent {
+		t.Fatalf("the event stream was not opened:\n%s", output)
+	}
+
+	checkEvent(t, output)
+}
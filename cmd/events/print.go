@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// eventLine is a single decoded event record as it is printed.
+type eventLine struct {
+	Timestamp   string
+	EventID     string `json:"EventId"`
+	EventType   string `json:",omitempty"`
+	Severity    string
+	MessageID   string `json:"MessageId"`
+	Message     string
+	MessageArgs []string `json:",omitempty"`
+	Resolution  string   `json:",omitempty"`
+	Origin      string   `json:",omitempty"`
+	Context     string   `json:",omitempty"`
+}
+
+// eventPrinter writes events as they arrive, either as aligned columns or as
+// one JSON object per line.
+type eventPrinter struct {
+	out        io.Writer
+	json       bool
+	prefixes   []string
+	messages   *utils.MessageRegistries
+	headerDone bool
+}
+
+// print decodes an event payload and writes each of its records.
+func (p *eventPrinter) print(payload []byte) error {
+	event := &redfish.Event{}
+	err := json.Unmarshal(payload, event)
+	if err != nil {
+		return utils.Error("unable to decode event: %v", err)
+	}
+
+	for i := range event.Events {
+		line := newEventLine(p.messages, event, &event.Events[i])
+
+		prefix, _, _ := strings.Cut(line.MessageID, ".")
+		if len(p.prefixes) > 0 && !slices.ContainsFunc(p.prefixes, func(s string) bool {
+			return strings.EqualFold(s, prefix)
+		}) {
+			continue
+		}
+
+		if p.json {
+			data, err := json.Marshal(line)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(p.out, string(data))
+			continue
+		}
+
+		if !p.headerDone {
+			fmt.Fprintf(p.out, "%-25s  %-8s  %-45s  %s\n", "TIMESTAMP", "SEVERITY", "MESSAGE ID", "MESSAGE")
+			p.headerDone = true
+		}
+
+		fmt.Fprintf(p.out, "%-25s  %-8s  %-45s  %s\n", line.Timestamp, line.Severity, line.MessageID, line.Message)
+		if line.Origin != "" {
+			fmt.Fprintf(p.out, "%-25s  %-8s  %-45s  origin: %s\n", "", "", "", line.Origin)
+		}
+	}
+
+	return nil
+}
+
+// newEventLine fills in the details of an event record, resolving the message
+// from the registries if needed.
+func newEventLine(messages *utils.MessageRegistries, event *redfish.Event, record *redfish.EventRecord) *eventLine {
+	line := &eventLine{
+		Timestamp:   record.EventTimestamp,
+		EventID:     record.EventID,
+		EventType:   string(record.EventType),
+		Severity:    string(record.MessageSeverity),
+		MessageID:   record.MessageID,
+		Message:     record.Message,
+		MessageArgs: record.MessageArgs,
+		Resolution:  record.Resolution,
+		Origin:      record.OriginOfCondition,
+		Context:     event.Context,
+	}
+
+	if line.Severity == "" {
+		line.Severity = record.Severity
+	}
+
+	resolved, ok := messages.Resolve(record.MessageID, record.MessageArgs)
+	if ok {
+		if line.Message == "" {
+			line.Message = resolved.Message
+		}
+		if line.Severity == "" {
+			line.Severity = resolved.Severity
+		}
+		if line.Resolution == "" {
+			line.Resolution = resolved.Resolution
+		}
+	}
+
+	if line.Message == "" {
+		line.Message = record.MessageID
+	}
+
+	return line
+}
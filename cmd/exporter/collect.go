@@ -0,0 +1,363 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// collector gathers the metrics of one connection.
+type collector struct {
+	client     common.Client
+	connection string
+	metrics    *metrics
+	errors     int
+	// reported holds the components whose health was added, as some, like
+	// drives, can be linked from more than one place.
+	reported map[string]bool
+}
+
+// scrape collects the metrics of a connection. Failing to connect is reported
+// through the up metric rather than as an error, as Prometheus expects.
+// Sessions are kept between scrapes, so if anything could not be retrieved the
+// session is dropped in case it has expired.
+func scrape(connection string) (*metrics, error) {
+	start := time.Now()
+	result := newMetrics()
+
+	c, err := utils.GofishClient(connection)
+	if err == nil {
+		defer c.Logout()
+
+		collector := &collector{client: c, connection: connection, metrics: result, reported: map[string]bool{}}
+		err = collector.collect()
+		result.gauge("scrape_errors", "Number of resources that could not be retrieved.",
+			float64(collector.errors), "connection", connection)
+		if err != nil || collector.errors > 0 {
+			utils.ForgetSession(connection)
+		}
+	}
+
+	up := 1.0
+	if err != nil {
+		up = 0
+	}
+	result.gauge("up", "Whether the service could be scraped.", up, "connection", connection)
+	result.gauge("scrape_duration_seconds", "Time taken to scrape the service.",
+		time.Since(start).Seconds(), "connection", connection)
+
+	return result, err
+}
+
+// collect walks the service, gathering metrics from everything it finds.
+func (c *collector) collect() error {
+	root, err := utils.GetResource(c.client, utils.ServiceRoot)
+	if err != nil {
+		return err
+	}
+
+	for _, system := range c.members(root, "Systems") {
+		c.system(system)
+	}
+
+	for _, chassis := range c.members(root, "Chassis") {
+		c.chassis(chassis)
+	}
+
+	for _, manager := range c.members(root, "Managers") {
+		c.health(manager, "manager")
+		c.firmware(manager, "FirmwareVersion")
+	}
+
+	if update, ok := c.get(root.Link("UpdateService")); ok {
+		for _, item := range c.members(update, "FirmwareInventory") {
+			c.firmware(item, "Version")
+		}
+	}
+
+	return nil
+}
+
+// system gathers the power state and health of a system and its components.
+func (c *collector) system(system utils.Resource) {
+	id, _ := system["Id"].(string)
+	name, _ := system["Name"].(string)
+	if state, ok := system["PowerState"].(string); ok {
+		on := 0.0
+		if state == string(redfish.OnPowerState) {
+			on = 1
+		}
+		c.metrics.gauge("system_power_on", "Whether the system is powered on.", on,
+			"connection", c.connection, "system", id, "name", name)
+	}
+
+	c.health(system, "system")
+	c.firmware(system, "BiosVersion")
+
+	for _, processor := range c.members(system, "Processors") {
+		c.health(processor, "processor")
+	}
+
+	for _, memory := range c.members(system, "Memory") {
+		c.health(memory, "memory")
+	}
+
+	for _, storage := range c.members(system, "Storage") {
+		c.storage(storage)
+	}
+}
+
+// storage gathers the health of the controllers and drives of a storage
+// subsystem.
+func (c *collector) storage(storage utils.Resource) {
+	for _, controller := range embedded(storage, "StorageControllers") {
+		c.health(controller, "storage_controller")
+	}
+
+	for _, controller := range c.members(storage, "Controllers") {
+		c.health(controller, "storage_controller")
+	}
+
+	drives, _ := storage["Drives"].([]interface{})
+	for _, link := range drives {
+		if drive, ok := c.get(utils.LinkURI(link)); ok {
+			c.health(drive, "drive")
+		}
+	}
+}
+
+// chassis gathers the health, power and thermal readings of a chassis.
+func (c *collector) chassis(chassis utils.Resource) {
+	c.health(chassis, "chassis")
+	id, _ := chassis["Id"].(string)
+
+	if power, ok := c.get(chassis.Link("Power")); ok {
+		for _, control := range embedded(power, "PowerControl") {
+			if watts, ok := number(control["PowerConsumedWatts"]); ok {
+				name, _ := control["Name"].(string)
+				c.powerConsumed(id, name, watts)
+			}
+		}
+
+		for _, supply := range embedded(power, "PowerSupplies") {
+			c.health(supply, "power_supply")
+		}
+	} else if subsystem, ok := c.get(chassis.Link("PowerSubsystem")); ok {
+		for _, supply := range c.members(subsystem, "PowerSupplies") {
+			c.health(supply, "power_supply")
+		}
+	}
+
+	if chassis.Link("Power") == "" {
+		// Newer services report the power consumed in the environment metrics
+		if metrics, ok := c.get(chassis.Link("EnvironmentMetrics")); ok {
+			power, _ := metrics["PowerWatts"].(map[string]interface{})
+			if watts, ok := number(power["Reading"]); ok {
+				c.powerConsumed(id, "Chassis", watts)
+			}
+		}
+	}
+
+	if thermal, ok := c.get(chassis.Link("Thermal")); ok {
+		c.thermal(id, thermal)
+	} else if subsystem, ok := c.get(chassis.Link("ThermalSubsystem")); ok {
+		c.thermalSubsystem(id, subsystem)
+	}
+}
+
+// thermal gathers the temperatures and fan speeds from a Thermal resource.
+func (c *collector) thermal(chassis string, thermal utils.Resource) {
+	for i, sensor := range embedded(thermal, "Temperatures") {
+		if reading, ok := number(sensor["ReadingCelsius"]); ok {
+			name, _ := sensor["Name"].(string)
+			c.temperature(chassis, sensorResource(thermal, sensor, "Temperatures", i), name, reading)
+		}
+	}
+
+	for i, fan := range embedded(thermal, "Fans") {
+		c.health(fan, "fan")
+
+		reading, ok := number(fan["Reading"])
+		if !ok {
+			continue
+		}
+		name, _ := fan["Name"].(string)
+		resource := sensorResource(thermal, fan, "Fans", i)
+		if units, _ := fan["ReadingUnits"].(string); units == "Percent" {
+			c.fanPercent(chassis, resource, name, reading)
+		} else {
+			c.fanRPM(chassis, resource, name, reading)
+		}
+	}
+}
+
+// thermalSubsystem gathers the temperatures and fan speeds from the newer
+// ThermalSubsystem resources.
+func (c *collector) thermalSubsystem(chassis string, subsystem utils.Resource) {
+	if metrics, ok := c.get(subsystem.Link("ThermalMetrics")); ok {
+		readings, _ := metrics["TemperatureReadingsCelsius"].([]interface{})
+		for i, entry := range readings {
+			reading, _ := entry.(map[string]interface{})
+			if value, ok := number(reading["Reading"]); ok {
+				source, _ := reading["DataSourceUri"].(string)
+				name, _ := reading["DeviceName"].(string)
+				if name == "" {
+					name = source
+				}
+
+				resource := relativePath(source)
+				if source == "" {
+					resource = sensorResource(metrics, reading, "TemperatureReadingsCelsius", i)
+				}
+				c.temperature(chassis, resource, name, value)
+			}
+		}
+	}
+
+	for _, fan := range c.members(subsystem, "Fans") {
+		c.health(fan, "fan")
+
+		name, _ := fan["Name"].(string)
+		resource := relativePath(fan.ID())
+		speed, _ := fan["SpeedPercent"].(map[string]interface{})
+		if percent, ok := number(speed["Reading"]); ok {
+			c.fanPercent(chassis, resource, name, percent)
+		}
+		if rpm, ok := number(speed["SpeedRPM"]); ok {
+			c.fanRPM(chassis, resource, name, rpm)
+		}
+	}
+}
+
+// temperature adds a temperature reading. Sensors are labelled with their
+// resource as well as their name, as names are not always unique.
+func (c *collector) temperature(chassis, resource, sensor string, celsius float64) {
+	c.metrics.gauge("temperature_celsius", "Temperature reading in degrees Celsius.", celsius,
+		"connection", c.connection, "chassis", chassis, "resource", resource, "sensor", sensor)
+}
+
+func (c *collector) fanRPM(chassis, resource, fan string, rpm float64) {
+	c.metrics.gauge("fan_speed_rpm", "Fan speed in revolutions per minute.", rpm,
+		"connection", c.connection, "chassis", chassis, "resource", resource, "fan", fan)
+}
+
+func (c *collector) fanPercent(chassis, resource, fan string, percent float64) {
+	c.metrics.gauge("fan_speed_percent", "Fan speed as a percentage of its maximum.", percent,
+		"connection", c.connection, "chassis", chassis, "resource", resource, "fan", fan)
+}
+
+func (c *collector) powerConsumed(chassis, name string, watts float64) {
+	c.metrics.gauge("power_consumed_watts", "Power consumed in watts.", watts,
+		"connection", c.connection, "chassis", chassis, "name", name)
+}
+
+// health adds the health of a component as 0 for OK, 1 for Warning and 2 for
+// Critical. Components without a health, or that are not installed, are left
+// out.
+func (c *collector) health(component utils.Resource, kind string) {
+	status, _ := component["Status"].(map[string]interface{})
+	health, _ := status["Health"].(string)
+	if health == "" || status["State"] == string(common.AbsentState) || c.reported[component.ID()] {
+		return
+	}
+	c.reported[component.ID()] = true
+
+	name, _ := component["Name"].(string)
+	c.metrics.gauge("health", "Health of a component (0 OK, 1 Warning, 2 Critical).",
+		float64(utils.HealthSeverity(common.Health(health))),
+		"connection", c.connection, "type", kind, "resource", relativePath(component.ID()), "name", name)
+}
+
+// firmware adds the firmware version of a component from the given property.
+func (c *collector) firmware(component utils.Resource, property string) {
+	version, _ := component[property].(string)
+	if version == "" {
+		return
+	}
+
+	name, _ := component["Name"].(string)
+	if property == "BiosVersion" {
+		name = "BIOS"
+	}
+	c.metrics.gauge("firmware_info", "Firmware version of a component, the value is always 1.", 1,
+		"connection", c.connection, "resource", relativePath(component.ID()), "name", name, "version", version)
+}
+
+// get retrieves a resource, counting any failure. Empty URIs are skipped.
+func (c *collector) get(uri string) (utils.Resource, bool) {
+	if uri == "" {
+		return nil, false
+	}
+
+	resource, err := utils.GetResource(c.client, uri)
+	if err != nil {
+		c.errors++
+		return nil, false
+	}
+
+	return resource, true
+}
+
+// members gets the members of a collection linked from a resource.
+func (c *collector) members(resource utils.Resource, property string) []utils.Resource {
+	collection, ok := c.get(resource.Link(property))
+	if !ok {
+		return nil
+	}
+
+	members := []utils.Resource{}
+	for _, uri := range collection.Members() {
+		if member, ok := c.get(uri); ok {
+			members = append(members, member)
+		}
+	}
+
+	return members
+}
+
+// embedded gets the objects in an array property of a resource, such as the
+// fans of a Thermal resource.
+func embedded(resource utils.Resource, property string) []utils.Resource {
+	result := []utils.Resource{}
+	items, _ := resource[property].([]interface{})
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			result = append(result, object)
+		}
+	}
+
+	return result
+}
+
+// sensorResource gets the path of a sensor in an array property of a
+// resource, using the index when the sensor has no ID of its own.
+func sensorResource(parent, sensor utils.Resource, property string, index int) string {
+	if id := sensor.ID(); id != "" {
+		return relativePath(id)
+	}
+
+	member, _ := sensor["MemberId"].(string)
+	if member == "" {
+		member = strconv.Itoa(index)
+	}
+
+	return relativePath(fmt.Sprintf("%s#/%s/%s", parent.ID(), property, member))
+}
+
+// number gets a JSON number, if the value is one.
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+	return n, ok
+}
+
+// relativePath shortens a URI to its path below the service root.
+func relativePath(uri string) string {
+	return strings.Trim(strings.TrimPrefix(utils.ResourceURI(uri), utils.ServiceRoot), "/")
+}
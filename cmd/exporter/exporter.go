@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/config"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Cmd gets the exporter command.
+func Cmd() *cobra.Command {
+	exporterCmd := &cobra.Command{
+		Use:   "exporter [CONNECTION_OR_GROUP...]",
+		Short: "Serve metrics for Prometheus.",
+		Long: dedent.Dedent(`Serve metrics for Prometheus from the saved connections, until
+		interrupted.
+
+		Scraping /metrics collects from the given connections and groups, or
+		from every saved connection if none are given. Scraping
+		/metrics?target=NAME collects from a single saved connection or group,
+		so one exporter can serve many scrape jobs. Only saved connections can
+		be scraped, using their saved credentials. A session is kept open for
+		each connection between scrapes, and logged out when the exporter
+		stops.
+
+		Power state, component health, temperatures, fan speeds, power
+		consumption and firmware versions are exported. Health is 0 for OK,
+		1 for Warning and 2 for Critical.`),
+		RunE: serveExporter,
+	}
+
+	exporterCmd.Flags().String("listen", ":9610", "Address to listen on.")
+	exporterCmd.Flags().SortFlags = true
+
+	return exporterCmd
+}
+
+// serveExporter runs the metrics endpoint.
+func serveExporter(cmd *cobra.Command, args []string) error {
+	connections, err := defaultConnections(args)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	address, _ := cmd.Flags().GetString("listen")
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return utils.ErrorExit(cmd, "unable to listen on '%s': %v", address, err)
+	}
+
+	// Logging in for every scrape would fill the session logs of the services
+	release := utils.KeepSessions()
+	defer release()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsHandler(cmd, connections))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><head><title>ctlfish exporter</title></head><body>`+
+			`<h1>ctlfish exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	cmd.PrintErrf("Serving metrics on http://%s/metrics, press Ctrl+C to stop.\n", listener.Addr())
+	err = server.Serve(listener)
+	if !errors.Is(err, http.ErrServerClosed) {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	return nil
+}
+
+// defaultConnections gets the connections scraped when no target is given,
+// which is every saved connection if none were named.
+func defaultConnections(names []string) ([]string, error) {
+	if len(names) > 0 {
+		return utils.Connections(names)
+	}
+
+	connections := []string{}
+	for name := range config.GetSystems() {
+		connections = append(connections, name)
+	}
+	if len(connections) == 0 {
+		return nil, utils.Error("there are no saved connections to scrape")
+	}
+	sort.Strings(connections)
+
+	return connections, nil
+}
+
+// metricsHandler scrapes the requested connections, writing their metrics.
+// Failed scrapes are logged and reported through the up metric.
+func metricsHandler(cmd *cobra.Command, connections []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targets := connections
+		if target := r.URL.Query().Get("target"); target != "" {
+			var err error
+			targets, err = utils.Connections([]string{target})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		results := make([]*metrics, len(targets))
+		utils.ForEachConnection(targets, func(i int, connection string) {
+			var err error
+			results[i], err = scrape(connection)
+			if err != nil {
+				cmd.PrintErrf("%s scrape of %s failed: %v\n", time.Now().Format(time.RFC3339), connection, err)
+			}
+		})
+
+		all := newMetrics()
+		for _, result := range results {
+			all.merge(result)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = all.write(w)
+	})
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestMetrics(t *testing.T) {
+	mockuptest.Start(t)
+	handler := metricsHandler(Cmd(), []string{"mockup"})
+
+	for _, uri := range []string{"/metrics", "/metrics?target=mockup"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, uri, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s failed with %d: %s", uri, recorder.Code, recorder.Body)
+		}
+
+		output := recorder.Body.String()
+		for _, expected := range []string{
+			"# TYPE ctlfish_up gauge",
+			`ctlfish_up{connection="mockup"} 1`,
+			`ctlfish_system_power_on{connection="mockup",system="1",name="web01"} 1`,
+			`ctlfish_health{connection="mockup",type="processor",resource="Systems/1/Processors/CPU2",name="Processor"} 0`,
+			`ctlfish_temperature_celsius{connection="mockup",chassis="1",resource="Chassis/1/Thermal#/Temperatures/1",sensor="Inlet Temp"} 23`,
+			`ctlfish_fan_speed_rpm{connection="mockup",chassis="1",resource="Chassis/1/Thermal#/Fans/0",fan="Fan 1"} 5400`,
+			`ctlfish_power_consumed_watts{connection="mockup",chassis="1",name="System Input Power"} 344`,
+			`ctlfish_firmware_info{connection="mockup",resource="Systems/1",name="BIOS",version="P79 v1.45 (12/06/2017)"} 1`,
+			`ctlfish_scrape_errors{connection="mockup"} 0`,
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%s output is missing %q:\n%s", uri, expected, output)
+			}
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics?target=unknown", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown target to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestMetricsSessions(t *testing.T) {
+	server := mockuptest.Start(t)
+	handler := metricsHandler(Cmd(), []string{"mockup"})
+
+	sessions := func() int {
+		collection, _ := server.Resource("/redfish/v1/SessionService/Sessions")
+		members, _ := collection["Members"].([]interface{})
+		return len(members)
+	}
+
+	release := utils.KeepSessions()
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if !strings.Contains(recorder.Body.String(), `ctlfish_up{connection="mockup"} 1`) {
+			t.Fatalf("scrape %d failed:\n%s", i, recorder.Body)
+		}
+	}
+
+	if count := sessions(); count != 1 {
+		t.Errorf("expected the session to be reused, found %d", count)
+	}
+	release()
+	if count := sessions(); count != 0 {
+		t.Errorf("expected the session to be logged out, found %d", count)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if escaped := escapeLabel("a \"b\"\\\nc"); escaped != `a \"b\"\\\nc` {
+		t.Errorf("unexpected escaping: %s", escaped)
+	}
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// metricPrefix starts the name of every metric.
+const metricPrefix = "ctlfish_"
+
+// family is a metric along with all of its samples.
+type family struct {
+	name    string
+	help    string
+	samples []string
+}
+
+// metrics collects samples in the Prometheus text format. Metrics are written
+// in the order they were first added.
+type metrics struct {
+	families []*family
+	byName   map[string]*family
+}
+
+func newMetrics() *metrics {
+	return &metrics{byName: map[string]*family{}}
+}
+
+// gauge adds a sample of a gauge metric. The labels are given as name and
+// value pairs.
+func (m *metrics) gauge(name, help string, value float64, labels ...string) {
+	sample := &strings.Builder{}
+	sample.WriteString(metricPrefix + name)
+	if len(labels) > 0 {
+		sample.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sample.WriteString(",")
+			}
+			fmt.Fprintf(sample, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		sample.WriteString("}")
+	}
+	sample.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64))
+
+	f := m.family(name, help)
+	f.samples = append(f.samples, sample.String())
+}
+
+// family gets the family of a metric, adding it if needed.
+func (m *metrics) family(name, help string) *family {
+	f, ok := m.byName[name]
+	if !ok {
+		f = &family{name: metricPrefix + name, help: help}
+		m.byName[name] = f
+		m.families = append(m.families, f)
+	}
+
+	return f
+}
+
+// merge adds the samples of other metrics after the existing ones.
+func (m *metrics) merge(other *metrics) {
+	for _, f := range other.families {
+		target := m.family(strings.TrimPrefix(f.name, metricPrefix), f.help)
+		target.samples = append(target.samples, f.samples...)
+	}
+}
+
+// write outputs the metrics in the Prometheus text format.
+func (m *metrics) write(w io.Writer) error {
+	for _, f := range m.families {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s\n",
+			f.name, f.help, f.name, strings.Join(f.samples, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// escapeLabel escapes a label value for the text format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
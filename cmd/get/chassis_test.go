@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestGetChassis(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "chassis")
+	if err != nil {
+		t.Fatalf("get chassis failed: %v", err)
+	}
+
+	if !strings.Contains(output, "Chassis1") || !strings.Contains(output, "On") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestGetChassisNotFound(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "chassis", "Chassis9")
+	if err == nil || !strings.Contains(err.Error(), "'Chassis9' was not found") {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
@@ -21,7 +21,7 @@ func getDrive(cmd *cobra.Command, args []string) error {
 	connection, _ := cmd.Flags().GetString("connection")
 	c, err := utils.GofishClient(connection)
 	if err != nil {
-		return utils.ErrorExit(cmd, err.Error())
+		return utils.ErrorExit(cmd, "%v", err)
 	}
 	defer c.Logout()
 
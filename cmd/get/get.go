@@ -2,20 +2,55 @@
 package get
 
 import (
+	"github.com/lithammer/dedent"
 	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/utils"
 )
 
+// commands are the get subcommands, all of which can be watched.
+var commands = []*cobra.Command{
+	chassisCmd,
+	driveCmd,
+	logCmd,
+	nicCmd,
+	pcieCmd,
+	powerCmd,
+	sensorCmd,
+	subscriptionCmd,
+	systemCmd,
+	thermalCmd,
+	userCmd,
+}
+
+func init() {
+	for _, command := range commands {
+		command.RunE = utils.Watchable(command.RunE)
+		command.Args = utils.WatchableArgs(command.Args)
+	}
+}
+
 func Cmd() *cobra.Command {
 	getCmd := &cobra.Command{
 		Use:     "get",
 		Aliases: []string{"g"},
 		Short:   "Get object information.",
+		Long: dedent.Dedent(`Get object information.
+
+		Any of the get commands can be rerun with --watch, redrawing the tables
+		in place with the cells that changed since the last poll highlighted,
+		until interrupted. The interval may be given as --watch=5s or after the
+		flag, as in "get system web01 --watch 5s". With --watch-json, a JSON
+		line is printed for each row that is new, changed or removed instead.
+		The same session is used for every poll.`),
+	}
+
+	for _, command := range commands {
+		getCmd.AddCommand(command)
 	}
 
-	getCmd.AddCommand(chassisCmd)
-	getCmd.AddCommand(driveCmd)
-	getCmd.AddCommand(systemCmd)
-	getCmd.AddCommand(userCmd)
+	utils.AddWatchFlags(getCmd)
+	getCmd.PersistentFlags().SortFlags = true
 
 	return getCmd
 }
@@ -0,0 +1,290 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var logCmd = &cobra.Command{
+	Use:     "log [SERVICE]",
+	Aliases: []string{"logs", "l"},
+	Short:   "Get log services and log entries.",
+	Long: dedent.Dedent(`Get log services and their entries.
+
+	Without arguments, lists the log services of all managers, systems and
+	chassis. When a log service ID, name, or URI is given, its entries are
+	shown, oldest first.`),
+	RunE: getLog,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func init() {
+	logCmd.Flags().String("since", "", "Only show entries created after this time (RFC 3339, YYYY-MM-DD, or a duration ago such as 24h or 7d).")
+	logCmd.Flags().String("until", "", "Only show entries created before this time (same formats as --since).")
+	logCmd.Flags().String("severity", "", "Only show entries at or above this severity (OK, Warning, Critical).")
+	logCmd.Flags().Int("limit", 0, "Only show the most recent number of entries.")
+	logCmd.Flags().BoolP("follow", "f", false, "Keep polling for new entries until interrupted.")
+	logCmd.Flags().Duration("interval", 5*time.Second, "How often to poll for new entries with --follow.")
+	logCmd.Flags().SortFlags = true
+}
+
+// logFilter holds the criteria for which log entries to show.
+type logFilter struct {
+	since    time.Time
+	until    time.Time
+	severity utils.Severity
+}
+
+// getLog lists the log services, or the entries of a single log service.
+func getLog(cmd *cobra.Command, args []string) error {
+	filter, err := parseLogFilter(cmd)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	follow, _ := cmd.Flags().GetBool("follow")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if follow && interval <= 0 {
+		return utils.ErrorExit(cmd, "the interval must be more than 0")
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	if len(args) == 0 {
+		services, err := utils.LogServices(c.Service)
+		if err != nil {
+			return utils.ErrorExit(cmd, "%v", err)
+		}
+
+		writer := utils.NewTableWriter(
+			cmd.OutOrStdout(), "id", "name", "source", "type", "enabled", "status", "uri")
+		for _, info := range services {
+			service := info.Service
+			writer.AddRow(
+				service.ID,
+				service.Name,
+				info.Source,
+				service.LogEntryType,
+				service.ServiceEnabled,
+				service.Status.Health,
+				service.ODataID)
+		}
+
+		writer.Render()
+		return nil
+	}
+
+	info, err := utils.FindLogService(c.Service, args[0])
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	entries, err := utils.LogEntries(info.Service)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve log entries: %v", err)
+	}
+
+	// Entries hidden by --limit are still known when following, so they are
+	// not shown as new
+	known := filter.apply(entries)
+	entries = known
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	messages := utils.NewMessageRegistries(c)
+	writer := utils.NewTableWriter(
+		cmd.OutOrStdout(), "id", "created", "severity", "message id", "message", "sensor")
+	for _, entry := range entries {
+		addLogEntryRow(writer, messages, entry)
+	}
+	writer.Render()
+
+	if follow {
+		return followLog(cmd, messages, info.Service, known, filter, interval)
+	}
+
+	return nil
+}
+
+// followLog polls the log service and prints any entries that appear that are
+// not already known.
+func followLog(
+	cmd *cobra.Command, messages *utils.MessageRegistries, service *redfish.LogService,
+	known []*redfish.LogEntry, filter *logFilter, interval time.Duration,
+) error {
+	seen := map[string]bool{}
+	for _, entry := range known {
+		seen[entry.ODataID] = true
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		entries, err := utils.LogEntries(service)
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to retrieve log entries: %v", err)
+		}
+
+		for _, entry := range filter.apply(entries) {
+			if seen[entry.ODataID] {
+				continue
+			}
+			seen[entry.ODataID] = true
+
+			fmt.Fprintf(
+				cmd.OutOrStdout(),
+				"%s  %-8s  %s  %s\n",
+				entry.Created, entry.Severity, entry.MessageID, entryMessage(messages, entry))
+		}
+	}
+}
+
+// addLogEntryRow adds the details of a log entry to the table.
+func addLogEntryRow(writer utils.TableOutputWriter, messages *utils.MessageRegistries, entry *redfish.LogEntry) {
+	sensor := string(entry.SensorType)
+	if entry.SensorType == "" {
+		sensor = entry.OemSensorType
+	}
+	if sensor != "" && entry.SensorNumber != 0 {
+		sensor = fmt.Sprintf("%s #%d", sensor, entry.SensorNumber)
+	}
+
+	writer.AddHighlightedRow(
+		entrySeverity(entry),
+		entry.ID,
+		entry.Created,
+		entry.Severity,
+		entry.MessageID,
+		entryMessage(messages, entry),
+		sensor)
+}
+
+// entryMessage gets the message of a log entry, resolving it from the message
+// registries if the service only provided the message ID.
+func entryMessage(messages *utils.MessageRegistries, entry *redfish.LogEntry) string {
+	if entry.MessageID == "" {
+		return entry.Message
+	}
+
+	return messages.Message(entry.MessageID, entry.Message, entry.MessageArgs)
+}
+
+// entrySeverity maps the event severity of a log entry to a severity. The
+// event severity values match those used for health.
+func entrySeverity(entry *redfish.LogEntry) utils.Severity {
+	return utils.HealthSeverity(common.Health(entry.Severity))
+}
+
+// apply returns the entries that match the filter criteria.
+func (f *logFilter) apply(entries []*redfish.LogEntry) []*redfish.LogEntry {
+	result := []*redfish.LogEntry{}
+	for _, entry := range entries {
+		created := utils.EntryTime(entry)
+		if !f.since.IsZero() && created.Before(f.since) {
+			continue
+		}
+
+		if !f.until.IsZero() && created.After(f.until) {
+			continue
+		}
+
+		if entrySeverity(entry) < f.severity {
+			continue
+		}
+
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+// parseLogFilter reads the filter criteria from the command flags.
+func parseLogFilter(cmd *cobra.Command) (*logFilter, error) {
+	filter := &logFilter{}
+
+	var err error
+	since, _ := cmd.Flags().GetString("since")
+	filter.since, err = parseTimeFlag(since)
+	if err != nil {
+		return nil, utils.Error("invalid --since value: %v", err)
+	}
+
+	until, _ := cmd.Flags().GetString("until")
+	filter.until, err = parseTimeFlag(until)
+	if err != nil {
+		return nil, utils.Error("invalid --until value: %v", err)
+	}
+
+	severity, _ := cmd.Flags().GetString("severity")
+	switch strings.ToLower(severity) {
+	case "", "ok":
+		filter.severity = utils.SeverityOK
+	case "warning":
+		filter.severity = utils.SeverityWarning
+	case "critical":
+		filter.severity = utils.SeverityCritical
+	default:
+		return nil, utils.Error("invalid --severity value '%s', must be OK, Warning, or Critical", severity)
+	}
+
+	return filter, nil
+}
+
+// parseTimeFlag parses an absolute time or a duration before now. Durations
+// may use a "d" suffix for days.
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Now().AddDate(0, 0, -count), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Now().Add(-duration), nil
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestGetLogInvalidInterval(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "log", "Log1", "--follow", "--interval", "0s")
+	if err == nil || !strings.Contains(err.Error(), "interval must be more than 0") {
+		t.Errorf("expected invalid interval error, got: %v", err)
+	}
+}
+
+func TestGetLogFollowLimit(t *testing.T) {
+	mockuptest.Start(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	logCmd.SetContext(ctx)
+	t.Cleanup(func() { logCmd.SetContext(context.Background()) })
+
+	// The entries hidden by --limit are not new, so following prints nothing
+	// more
+	output, err := mockuptest.Run(t, Cmd(), "log", "Log1", "--limit", "1", "--follow", "--interval", "50ms")
+	if err != nil {
+		t.Fatalf("get log failed: %v\n%s", err, output)
+	}
+
+	if !strings.Contains(output, "ResourceErrorsCorrected") {
+		t.Errorf("expected the last entry to be shown:\n%s", output)
+	}
+	for _, hidden := range []string{"ResourceCreated", "ResourceErrorsDetected"} {
+		if strings.Contains(output, hidden) {
+			t.Errorf("expected %s to stay hidden:\n%s", hidden, output)
+		}
+	}
+}
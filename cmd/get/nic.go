@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var nicCmd = &cobra.Command{
+	Use:     "nic [NAME_OR_ID]",
+	Aliases: []string{"nics", "n"},
+	Short:   "Get network adapter and interface information.",
+	Long: "Get network adapter ports and host ethernet interfaces, including MAC " +
+		"addresses, link status, speed, firmware, and PCIe location.",
+	RunE: getNIC,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func init() {
+	nicCmd.Flags().Bool("macs-only", false, "Only print MAC addresses, one per line.")
+}
+
+// nicInfo is a flattened view of a single network port or interface.
+type nicInfo struct {
+	id       string
+	name     string
+	port     string
+	macs     []string
+	link     string
+	speed    string
+	firmware string
+	location string
+}
+
+// getNIC retrieves the network adapter information.
+func getNIC(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	chassis, err := c.Service.Chassis()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve chassis information: %v", err)
+	}
+
+	nics := []nicInfo{}
+	for _, chass := range chassis {
+		adapters, err := chass.NetworkAdapters()
+		if err != nil {
+			return utils.ErrorExit(
+				cmd,
+				"failed to get network adapters for chassis %q: %v",
+				chass.Name, err)
+		}
+
+		for _, adapter := range adapters {
+			adapterNICs, err := adapterInfo(adapter)
+			if err != nil {
+				return utils.ErrorExit(
+					cmd,
+					"failed to get ports for network adapter %q: %v",
+					adapter.Name, err)
+			}
+			nics = append(nics, adapterNICs...)
+		}
+	}
+
+	systems, err := c.Service.Systems()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve system information: %v", err)
+	}
+
+	for _, sys := range systems {
+		interfaces, err := sys.EthernetInterfaces()
+		if err != nil {
+			return utils.ErrorExit(
+				cmd,
+				"failed to get ethernet interfaces for system %q: %v",
+				sys.Name, err)
+		}
+
+		for _, iface := range interfaces {
+			nics = append(nics, nicInfo{
+				id:    iface.ID,
+				name:  iface.Name,
+				macs:  nonEmpty(iface.MACAddress),
+				link:  string(iface.LinkStatus),
+				speed: linkSpeed(float32(iface.SpeedMbps)),
+			})
+		}
+	}
+
+	if len(args) == 1 {
+		filtered := []nicInfo{}
+		for _, nic := range nics {
+			if nic.id == args[0] || nic.name == args[0] {
+				filtered = append(filtered, nic)
+			}
+		}
+
+		if len(filtered) == 0 {
+			return utils.ErrorExit(cmd, "network adapter '%s' was not found.", args[0])
+		}
+		nics = filtered
+	}
+
+	macsOnly, _ := cmd.Flags().GetBool("macs-only")
+	if macsOnly {
+		printMACs(cmd, nics)
+		return nil
+	}
+
+	writer := utils.NewTableWriter(
+		cmd.OutOrStdout(),
+		"name", "port", "mac", "link", "speed", "firmware", "location")
+	for _, nic := range nics {
+		writer.AddRow(
+			nic.name,
+			nic.port,
+			strings.Join(nic.macs, ", "),
+			nic.link,
+			nic.speed,
+			nic.firmware,
+			nic.location)
+	}
+
+	writer.Render()
+	return nil
+}
+
+// printMACs prints each unique MAC address on its own line so the output can
+// be piped into other tools.
+func printMACs(cmd *cobra.Command, nics []nicInfo) {
+	// Same MAC may be reported by the adapter and the host interface
+	seen := map[string]bool{}
+	for _, nic := range nics {
+		for _, mac := range nic.macs {
+			mac = strings.ToLower(mac)
+			if seen[mac] {
+				continue
+			}
+			seen[mac] = true
+			fmt.Fprintln(cmd.OutOrStdout(), mac)
+		}
+	}
+}
+
+// adapterInfo gathers the port details for a network adapter. Newer services
+// expose Ports, older ones NetworkPorts, and some only report MAC addresses on
+// their NetworkDeviceFunctions.
+func adapterInfo(adapter *redfish.NetworkAdapter) ([]nicInfo, error) {
+	base := nicInfo{
+		id:       adapter.ID,
+		name:     adapter.Name,
+		location: adapter.Location.PartLocation.ServiceLabel,
+	}
+
+	if len(adapter.Controllers) > 0 {
+		controller := adapter.Controllers[0]
+		base.firmware = controller.FirmwarePackageVersion
+		if controller.Location.PartLocation.ServiceLabel != "" {
+			base.location = controller.Location.PartLocation.ServiceLabel
+		}
+
+		if link := pcieLink(controller.PCIeInterface); link != "" {
+			base.location = strings.TrimSpace(fmt.Sprintf("%s %s", base.location, link))
+		}
+	}
+
+	result := []nicInfo{}
+
+	ports, err := adapter.Ports()
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range ports {
+		nic := base
+		nic.port = port.PortID
+		nic.macs = port.Ethernet.AssociatedMACAddresses
+		nic.link = string(port.LinkStatus)
+		nic.speed = linkSpeed(port.CurrentSpeedGbps * 1000)
+		result = append(result, nic)
+	}
+
+	if len(result) == 0 {
+		networkPorts, err := adapter.NetworkPorts()
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range networkPorts {
+			nic := base
+			nic.port = port.PhysicalPortNumber
+			nic.macs = port.AssociatedNetworkAddresses
+			nic.link = string(port.LinkStatus)
+			nic.speed = linkSpeed(float32(port.CurrentLinkSpeedMbps))
+			result = append(result, nic)
+		}
+	}
+
+	// Fill in any missing MAC addresses from the device functions
+	functions, err := adapter.NetworkDeviceFunctions()
+	if err != nil {
+		return nil, err
+	}
+	for _, function := range functions {
+		mac := function.Ethernet.MACAddress
+		if mac == "" {
+			mac = function.Ethernet.PermanentMACAddress
+		}
+		if mac == "" || hasMAC(result, mac) {
+			continue
+		}
+
+		nic := base
+		nic.port = function.ID
+		nic.macs = []string{mac}
+		result = append(result, nic)
+	}
+
+	if len(result) == 0 {
+		result = append(result, base)
+	}
+
+	return result, nil
+}
+
+// hasMAC checks whether a MAC address has already been collected.
+func hasMAC(nics []nicInfo, mac string) bool {
+	for _, nic := range nics {
+		for _, existing := range nic.macs {
+			if strings.EqualFold(existing, mac) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nonEmpty returns a single element slice, or an empty one if value is blank.
+func nonEmpty(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	return []string{value}
+}
+
+// linkSpeed formats a link speed given in Mbps.
+func linkSpeed(mbps float32) string {
+	if mbps <= 0 {
+		return ""
+	}
+
+	if mbps >= 1000 {
+		return fmt.Sprintf("%g Gbps", mbps/1000)
+	}
+
+	return fmt.Sprintf("%g Mbps", mbps)
+}
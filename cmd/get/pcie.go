@@ -0,0 +1,179 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var pcieCmd = &cobra.Command{
+	Use:     "pcie [NAME_OR_ID]",
+	Aliases: []string{"pci", "p"},
+	Short:   "Get PCIe device information.",
+	Long: "Get PCIe devices and their functions, including device class, " +
+		"vendor and device IDs, slot, link width and speed, and health.",
+	RunE: getPCIe,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func init() {
+	pcieCmd.Flags().Bool("functions", false, "List each PCIe function separately.")
+}
+
+// getPCIe retrieves the PCIe device information.
+func getPCIe(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	systems, err := c.Service.Systems()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve system information: %v", err)
+	}
+
+	chassis, err := c.Service.Chassis()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve chassis information: %v", err)
+	}
+
+	devices, err := collectPCIeDevices(systems, chassis)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	showFunctions, _ := cmd.Flags().GetBool("functions")
+	headers := []string{"name", "class", "vendor:device", "slot", "link", "status", "manufacturer", "model"}
+	if showFunctions {
+		headers = []string{"name", "function", "class", "vendor:device", "slot", "link", "status"}
+	}
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), headers...)
+
+	for _, device := range devices {
+		if len(args) == 1 && (device.ID != args[0] && device.Name != args[0]) {
+			continue
+		}
+
+		functions, err := device.PCIeFunctions()
+		if err != nil {
+			return utils.ErrorExit(
+				cmd,
+				"failed to get PCIe functions for device %q: %v",
+				device.Name, err)
+		}
+
+		slot := device.Slot.Location.PartLocation.ServiceLabel
+		link := pcieLink(device.PCIeInterface)
+
+		if showFunctions {
+			for _, function := range functions {
+				writer.AddRow(
+					device.Name,
+					function.FunctionID,
+					function.DeviceClass,
+					pciID(function),
+					slot,
+					link,
+					function.Status.Health)
+			}
+			continue
+		}
+
+		classes := []string{}
+		ids := ""
+		for _, function := range functions {
+			class := string(function.DeviceClass)
+			if class != "" && !slices.Contains(classes, class) {
+				classes = append(classes, class)
+			}
+			if ids == "" {
+				ids = pciID(function)
+			}
+		}
+
+		writer.AddRow(
+			device.Name,
+			strings.Join(classes, ", "),
+			ids,
+			slot,
+			link,
+			device.Status.Health,
+			device.Manufacturer,
+			device.Model)
+	}
+
+	if len(args) != 0 && writer.RowCount() == 0 {
+		return utils.ErrorExit(cmd, "PCIe device '%s' was not found.", args[0])
+	}
+
+	writer.Render()
+	return nil
+}
+
+// pciID formats the vendor and device IDs of a function in the familiar
+// lspci style.
+func pciID(function *redfish.PCIeFunction) string {
+	if function.VendorID == "" && function.DeviceID == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"%s:%s",
+		strings.TrimPrefix(strings.ToLower(function.VendorID), "0x"),
+		strings.TrimPrefix(strings.ToLower(function.DeviceID), "0x"))
+}
+
+// pcieLink formats the negotiated PCIe generation and lane count.
+func pcieLink(pcie redfish.PCIeInterface) string {
+	if pcie.PCIeType == "" {
+		return ""
+	}
+
+	if pcie.LanesInUse == 0 {
+		return string(pcie.PCIeType)
+	}
+
+	return fmt.Sprintf("%s x%d", pcie.PCIeType, pcie.LanesInUse)
+}
+
+// collectPCIeDevices gathers the PCIe devices of all systems and chassis.
+// Devices may be linked from both, so duplicates are skipped.
+func collectPCIeDevices(systems []*redfish.ComputerSystem, chassis []*redfish.Chassis) ([]*redfish.PCIeDevice, error) {
+	devices := []*redfish.PCIeDevice{}
+	seen := map[string]bool{}
+	addDevices := func(found []*redfish.PCIeDevice) {
+		for _, device := range found {
+			if seen[device.ODataID] {
+				continue
+			}
+			seen[device.ODataID] = true
+			devices = append(devices, device)
+		}
+	}
+
+	for _, sys := range systems {
+		sysDevices, err := sys.PCIeDevices()
+		if err != nil {
+			return nil, utils.Error("failed to get PCIe devices for system %q: %v", sys.Name, err)
+		}
+		addDevices(sysDevices)
+	}
+
+	for _, chass := range chassis {
+		chassDevices, err := chass.PCIeDevices()
+		if err != nil {
+			return nil, utils.Error("failed to get PCIe devices for chassis %q: %v", chass.Name, err)
+		}
+		addDevices(chassDevices)
+	}
+
+	return devices, nil
+}
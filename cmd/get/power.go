@@ -0,0 +1,282 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var powerCmd = &cobra.Command{
+	Use:   "power [CHASSIS]",
+	Short: "Get chassis power telemetry.",
+	Long: "Get power consumption and limits, power supply status, and voltage " +
+		"readings for each chassis. Readings outside their thresholds are highlighted.",
+	RunE: getPower,
+	Args: cobra.MaximumNArgs(1),
+}
+
+// powerTables holds the output tables for the power command.
+type powerTables struct {
+	control  utils.TableOutputWriter
+	supplies utils.TableOutputWriter
+	voltages utils.TableOutputWriter
+}
+
+// getPower retrieves the power information for the chassis.
+func getPower(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	chassis, err := c.Service.Chassis()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve chassis information: %v", err)
+	}
+
+	out := cmd.OutOrStdout()
+	tables := &powerTables{
+		control: utils.NewTableWriter(
+			out, "chassis", "name", "consumed", "capacity", "limit", "exception"),
+		supplies: utils.NewTableWriter(
+			out, "chassis", "name", "status", "input", "output", "capacity", "efficiency", "firmware"),
+		voltages: utils.NewTableWriter(
+			out, "chassis", "name", "reading", "lower critical", "upper critical", "status"),
+	}
+
+	found := false
+	for _, chass := range chassis {
+		if len(args) == 1 && (chass.ID != args[0] && chass.Name != args[0]) {
+			continue
+		}
+		found = true
+
+		power, err := chass.Power()
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to get power information for chassis %q: %v", chass.Name, err)
+		}
+
+		if power != nil {
+			addLegacyPower(tables, chass, power)
+			continue
+		}
+
+		err = addPowerSubsystem(tables, chass)
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to get power information for chassis %q: %v", chass.Name, err)
+		}
+	}
+
+	if len(args) != 0 && !found {
+		return utils.ErrorExit(cmd, "chassis '%s' was not found.", args[0])
+	}
+
+	renderTables(out, tables.control, tables.supplies, tables.voltages)
+	return nil
+}
+
+// addLegacyPower adds the details from the deprecated Power resource.
+func addLegacyPower(tables *powerTables, chass *redfish.Chassis, power *redfish.Power) {
+	for i := range power.PowerControl {
+		control := &power.PowerControl[i]
+		exception := ""
+		limit := ""
+		if control.PowerLimit.LimitInWatts > 0 {
+			limit = formatReading(control.PowerLimit.LimitInWatts, "W")
+			exception = string(control.PowerLimit.LimitException)
+		}
+
+		tables.control.AddHighlightedRow(
+			utils.HealthSeverity(control.Status.Health),
+			chass.Name,
+			control.Name,
+			formatReading(control.PowerConsumedWatts, "W"),
+			formatOptional(control.PowerCapacityWatts, "W"),
+			limit,
+			exception)
+	}
+
+	for i := range power.PowerSupplies {
+		supply := &power.PowerSupplies[i]
+		tables.supplies.AddHighlightedRow(
+			utils.HealthSeverity(supply.Status.Health),
+			chass.Name,
+			supply.Name,
+			supply.Status.Health,
+			formatOptional(supply.PowerInputWatts, "W"),
+			formatOptional(supply.PowerOutputWatts, "W"),
+			formatOptional(supply.PowerCapacityWatts, "W"),
+			formatOptional(supply.EfficiencyPercent, "%"),
+			supply.FirmwareVersion)
+	}
+
+	for i := range power.Voltages {
+		voltage := &power.Voltages[i]
+		severity := utils.MaxSeverity(
+			utils.HealthSeverity(voltage.Status.Health),
+			utils.ThresholdSeverity(
+				voltage.ReadingVolts,
+				voltage.LowerThresholdCritical,
+				voltage.LowerThresholdNonCritical,
+				voltage.UpperThresholdNonCritical,
+				voltage.UpperThresholdCritical))
+
+		tables.voltages.AddHighlightedRow(
+			severity,
+			chass.Name,
+			voltage.Name,
+			formatReading(voltage.ReadingVolts, "V"),
+			formatOptional(voltage.LowerThresholdCritical, "V"),
+			formatOptional(voltage.UpperThresholdCritical, "V"),
+			severity)
+	}
+}
+
+// addPowerSubsystem adds the details from the PowerSubsystem, EnvironmentMetrics
+// and Sensors resources used by newer services.
+func addPowerSubsystem(tables *powerTables, chass *redfish.Chassis) error {
+	subsystem, err := chass.PowerSubsystem()
+	if err != nil {
+		return err
+	}
+
+	metrics, err := chass.EnvironmentMetrics()
+	if err != nil {
+		return err
+	}
+
+	if metrics != nil {
+		capacity := float32(0)
+		if subsystem != nil {
+			capacity = float32(subsystem.CapacityWatts)
+		}
+
+		limit := ""
+		if metrics.PowerLimitWatts.SetPoint > 0 {
+			limit = formatReading(float32(metrics.PowerLimitWatts.SetPoint), "W")
+		}
+
+		tables.control.AddRow(
+			chass.Name,
+			metrics.Name,
+			formatReading(metrics.PowerWatts.Reading, "W"),
+			formatOptional(capacity, "W"),
+			limit,
+			metrics.PowerLimitWatts.ControlMode)
+	}
+
+	if subsystem != nil {
+		supplies, err := subsystem.PowerSupplies()
+		if err != nil {
+			return err
+		}
+
+		for _, supply := range supplies {
+			err = addPowerSupplyUnit(tables, chass, supply)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	sensors, err := chass.Sensors()
+	if err != nil {
+		return err
+	}
+
+	for _, sensor := range sensors {
+		if sensor.ReadingType != redfish.VoltageReadingType {
+			continue
+		}
+
+		severity := utils.SensorSeverity(sensor)
+		tables.voltages.AddHighlightedRow(
+			severity,
+			chass.Name,
+			sensor.Name,
+			formatReading(sensor.Reading, "V"),
+			formatOptional(sensor.Thresholds.LowerCritical.Reading, "V"),
+			formatOptional(sensor.Thresholds.UpperCritical.Reading, "V"),
+			severity)
+	}
+
+	return nil
+}
+
+// addPowerSupplyUnit adds the details of a PowerSubsystem power supply. The
+// readings are only available from its metrics resource.
+func addPowerSupplyUnit(tables *powerTables, chass *redfish.Chassis, supply *redfish.PowerSupply) error {
+	unit, err := redfish.GetPowerSupplyUnit(supply.GetClient(), supply.ODataID)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := unit.Metrics()
+	if err != nil {
+		return err
+	}
+
+	input := ""
+	output := ""
+	if metrics != nil {
+		input = formatOptional(metrics.InputPowerWatts.Reading, "W")
+		output = formatOptional(metrics.OutputPowerWatts.Reading, "W")
+	}
+
+	efficiency := float32(0)
+	if len(unit.EfficiencyRatings) > 0 {
+		efficiency = unit.EfficiencyRatings[0].EfficiencyPercent
+	}
+
+	tables.supplies.AddHighlightedRow(
+		utils.HealthSeverity(unit.Status.Health),
+		chass.Name,
+		unit.Name,
+		unit.Status.Health,
+		input,
+		output,
+		formatOptional(unit.PowerCapacityWatts, "W"),
+		formatOptional(efficiency, "%"),
+		unit.FirmwareVersion)
+
+	return nil
+}
+
+// renderTables emits each table that has content.
+func renderTables(out io.Writer, tables ...utils.TableOutputWriter) {
+	rendered := false
+	for _, table := range tables {
+		if table.RowCount() == 0 {
+			continue
+		}
+
+		table.Render()
+		rendered = true
+	}
+
+	if !rendered {
+		fmt.Fprintln(out, "No information available.")
+	}
+}
+
+// formatReading formats a sensor reading with its units.
+func formatReading(value float32, units string) string {
+	return fmt.Sprintf("%g %s", value, units)
+}
+
+// formatOptional formats a threshold, rating or reading with its units.
+// Services leave out values they do not report, so zero is shown as blank.
+func formatOptional(value float32, units string) string {
+	if value == 0 {
+		return ""
+	}
+
+	return formatReading(value, units)
+}
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestGetPower(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "power")
+	if err != nil {
+		t.Fatalf("get power failed: %v", err)
+	}
+
+	for _, expected := range []string{"344 W", "800 W", "500 W", "LogEventOnly", "Power Supply Bay 1"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+}
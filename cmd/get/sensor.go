@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var sensorCmd = &cobra.Command{
+	Use:     "sensor [NAME_OR_ID]",
+	Aliases: []string{"sensors"},
+	Short:   "Get sensor readings.",
+	Long: dedent.Dedent(`Get sensor readings and thresholds from the chassis Sensors collections.
+
+	Readings are evaluated against their thresholds. The exit code reflects the
+	most severe displayed sensor (0 OK, 1 Warning, 2 Critical), so this can be
+	used as a monitoring health check. If the sensors cannot be read, the exit
+	code is 3.`),
+	RunE: getSensor,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func init() {
+	sensorCmd.Flags().StringSlice("type", []string{}, "Only show sensors of this reading type (Temperature, Power, Current, Voltage, Humidity, AirFlow, ...).")
+	sensorCmd.Flags().StringSlice("chassis", []string{}, "Only show sensors from this chassis name or ID.")
+	sensorCmd.Flags().Bool("alerting", false, "Only show sensors outside their thresholds or not healthy.")
+	sensorCmd.Flags().SortFlags = true
+	utils.MarkMonitoring(sensorCmd)
+}
+
+// getSensor retrieves the sensor information from each chassis.
+func getSensor(cmd *cobra.Command, args []string) error {
+	types, _ := cmd.Flags().GetStringSlice("type")
+	chassisFilter, _ := cmd.Flags().GetStringSlice("chassis")
+	alerting, _ := cmd.Flags().GetBool("alerting")
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	chassis, err := c.Service.Chassis()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve chassis information: %v", err)
+	}
+
+	worst := utils.SeverityOK
+	found := false
+	writer := utils.NewTableWriter(
+		cmd.OutOrStdout(),
+		"chassis", "name", "type", "reading",
+		"lower critical", "lower caution", "upper caution", "upper critical", "status")
+	for _, chass := range chassis {
+		if len(chassisFilter) > 0 && !matchesAny(chassisFilter, chass.ID, chass.Name) {
+			continue
+		}
+
+		sensors, err := chass.Sensors()
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to get sensors for chassis %q: %v", chass.Name, err)
+		}
+
+		for _, sensor := range sensors {
+			if len(args) == 1 && (sensor.ID != args[0] && sensor.Name != args[0]) {
+				continue
+			}
+			// A named sensor that is filtered out below is still reported as OK
+			found = true
+
+			if len(types) > 0 && !matchesAny(types, string(sensor.ReadingType)) {
+				continue
+			}
+
+			severity := utils.SensorSeverity(sensor)
+			if alerting && severity == utils.SeverityOK {
+				continue
+			}
+			worst = utils.MaxSeverity(worst, severity)
+
+			addSensorRow(writer, chass, sensor, severity)
+		}
+	}
+
+	if len(args) != 0 && !found {
+		return utils.ErrorExit(cmd, "sensor '%s' was not found.", args[0])
+	}
+
+	writer.Render()
+	return utils.SeverityExit(cmd, worst)
+}
+
+// addSensorRow adds the details of a single sensor to the table.
+func addSensorRow(writer utils.TableOutputWriter, chass *redfish.Chassis, sensor *redfish.Sensor, severity utils.Severity) {
+	units := sensor.ReadingUnits
+	thresholds := sensor.Thresholds
+	writer.AddHighlightedRow(
+		severity,
+		chass.Name,
+		sensor.Name,
+		sensor.ReadingType,
+		formatReading(sensor.Reading, units),
+		formatOptional(thresholds.LowerCritical.Reading, units),
+		formatOptional(thresholds.LowerCaution.Reading, units),
+		formatOptional(thresholds.UpperCaution.Reading, units),
+		formatOptional(thresholds.UpperCritical.Reading, units),
+		severity)
+}
+
+// matchesAny checks if any of the values case insensitively match one of the
+// filter entries.
+func matchesAny(filter []string, values ...string) bool {
+	for _, entry := range filter {
+		for _, value := range values {
+			if strings.EqualFold(entry, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestGetSensor(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "sensor")
+	if code := utils.ExitCode(sensorCmd, err); code != int(utils.SeverityWarning) {
+		t.Errorf("expected the warning exit code, got %d: %v", code, err)
+	}
+
+	for _, expected := range []string{"CPU1 Temp", "81 Cel", "P12V", "12.1 V"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+}
+
+func TestGetSensorAlerting(t *testing.T) {
+	mockuptest.Start(t)
+
+	// A sensor that is OK is left out, but it was found
+	output, err := mockuptest.Run(t, Cmd(), "sensor", "P12V", "--alerting")
+	if err != nil {
+		t.Fatalf("get sensor failed: %v\n%s", err, output)
+	}
+	if strings.Contains(output, "P12V") {
+		t.Errorf("expected the sensor to be left out:\n%s", output)
+	}
+
+	_, err = mockuptest.Run(t, Cmd(), "sensor", "P5V", "--alerting")
+	if err == nil || !strings.Contains(err.Error(), "sensor 'P5V' was not found") {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+	if code := utils.ExitCode(sensorCmd, err); code != utils.ExitUnknown {
+		t.Errorf("expected the unknown exit code, got %d", code)
+	}
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var subscriptionCmd = &cobra.Command{
+	Use:     "subscription [ID]",
+	Aliases: []string{"subscriptions", "sub"},
+	Short:   "Get event subscriptions.",
+	Long:    "Get details for a specified event subscription or list all subscriptions.",
+	RunE:    getSubscription,
+	Args:    cobra.MaximumNArgs(1),
+}
+
+// getSubscription retrieves the event subscriptions from the event service.
+func getSubscription(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	eventService, err := c.Service.EventService()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to access event service: %v", err)
+	}
+
+	subscriptions, err := eventService.GetEventSubscriptions()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve subscriptions: %v", err)
+	}
+
+	writer := utils.NewTableWriter(
+		cmd.OutOrStdout(),
+		"id", "destination", "protocol", "context", "event types", "registry prefixes", "resource types", "state")
+	for _, subscription := range subscriptions {
+		if len(args) == 1 && (subscription.ID != args[0] && subscription.ODataID != args[0]) {
+			continue
+		}
+
+		eventTypes := []string{}
+		for _, eventType := range subscription.EventTypes {
+			eventTypes = append(eventTypes, string(eventType))
+		}
+
+		writer.AddRow(
+			subscription.ID,
+			subscription.Destination,
+			subscription.Protocol,
+			subscription.Context,
+			strings.Join(eventTypes, ", "),
+			strings.Join(subscription.RegistryPrefixes, ", "),
+			strings.Join(subscription.ResourceTypes, ", "),
+			subscription.Status.State)
+	}
+
+	if len(args) != 0 && writer.RowCount() == 0 {
+		return utils.ErrorExit(cmd, "subscription '%s' was not found.", args[0])
+	}
+
+	writer.Render()
+	return nil
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestGetSystem(t *testing.T) {
+	mockuptest.Start(t)
+
+	for _, args := range [][]string{{"system"}, {"system", "1"}, {"system", "web01"}} {
+		output, err := mockuptest.Run(t, Cmd(), args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+
+		for _, expected := range []string{"web01", "96.00 GB", "On", "OK", "Web server"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("%v output is missing %q:\n%s", args, expected, output)
+			}
+		}
+	}
+}
+
+func TestGetSystemNotFound(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "system", "db01")
+	if err == nil || err.Error() != "system 'db01' was not found." {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var thermalCmd = &cobra.Command{
+	Use:   "thermal [CHASSIS]",
+	Short: "Get chassis thermal telemetry.",
+	Long: "Get fan speeds and redundancy, and temperature readings with their " +
+		"thresholds for each chassis. Readings outside their thresholds are highlighted.",
+	RunE: getThermal,
+	Args: cobra.MaximumNArgs(1),
+}
+
+// thermalTables holds the output tables for the thermal command.
+type thermalTables struct {
+	fans         utils.TableOutputWriter
+	redundancy   utils.TableOutputWriter
+	temperatures utils.TableOutputWriter
+}
+
+// getThermal retrieves the thermal information for the chassis.
+func getThermal(cmd *cobra.Command, args []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	chassis, err := c.Service.Chassis()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve chassis information: %v", err)
+	}
+
+	out := cmd.OutOrStdout()
+	tables := &thermalTables{
+		fans: utils.NewTableWriter(
+			out, "chassis", "name", "reading", "lower critical", "status"),
+		redundancy: utils.NewTableWriter(
+			out, "chassis", "name", "mode", "min needed", "status"),
+		temperatures: utils.NewTableWriter(
+			out, "chassis", "name", "reading", "upper caution", "upper critical", "upper fatal", "status"),
+	}
+
+	found := false
+	for _, chass := range chassis {
+		if len(args) == 1 && (chass.ID != args[0] && chass.Name != args[0]) {
+			continue
+		}
+		found = true
+
+		thermal, err := chass.Thermal()
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to get thermal information for chassis %q: %v", chass.Name, err)
+		}
+
+		if thermal != nil {
+			err = addLegacyThermal(tables, chass, thermal)
+		} else {
+			err = addThermalSubsystem(tables, chass)
+		}
+
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to get thermal information for chassis %q: %v", chass.Name, err)
+		}
+	}
+
+	if len(args) != 0 && !found {
+		return utils.ErrorExit(cmd, "chassis '%s' was not found.", args[0])
+	}
+
+	renderTables(out, tables.fans, tables.redundancy, tables.temperatures)
+	return nil
+}
+
+// addLegacyThermal adds the details from the deprecated Thermal resource.
+func addLegacyThermal(tables *thermalTables, chass *redfish.Chassis, thermal *redfish.Thermal) error {
+	for i := range thermal.Fans {
+		fan := &thermal.Fans[i]
+		severity := utils.MaxSeverity(
+			utils.HealthSeverity(fan.Status.Health),
+			utils.ThresholdSeverity(
+				float32(fan.Reading),
+				float32(fan.LowerThresholdCritical),
+				float32(fan.LowerThresholdNonCritical),
+				float32(fan.UpperThresholdNonCritical),
+				float32(fan.UpperThresholdCritical)))
+
+		units := "RPM"
+		if fan.ReadingUnits == redfish.PercentReadingUnits {
+			units = "%"
+		}
+
+		tables.fans.AddHighlightedRow(
+			severity,
+			chass.Name,
+			fan.Name,
+			formatReading(float32(fan.Reading), units),
+			formatOptional(float32(fan.LowerThresholdCritical), units),
+			severity)
+	}
+
+	// The Thermal resource embeds its redundancy objects, but gofish only keeps
+	// the links, so decode them from the raw resource.
+	resp, err := thermal.GetClient().Get(thermal.ODataID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var raw struct {
+		Redundancy []redfish.Redundancy
+	}
+	err = json.NewDecoder(resp.Body).Decode(&raw)
+	if err != nil {
+		return err
+	}
+
+	for i := range raw.Redundancy {
+		redundancy := &raw.Redundancy[i]
+		tables.redundancy.AddHighlightedRow(
+			utils.HealthSeverity(redundancy.Status.Health),
+			chass.Name,
+			redundancy.Name,
+			redundancy.Mode,
+			redundancy.MinNumNeeded,
+			redundancy.Status.Health)
+	}
+
+	for i := range thermal.Temperatures {
+		temp := &thermal.Temperatures[i]
+		severity := utils.MaxSeverity(
+			utils.HealthSeverity(temp.Status.Health),
+			utils.ThresholdSeverity(
+				temp.ReadingCelsius,
+				temp.LowerThresholdCritical,
+				temp.LowerThresholdNonCritical,
+				temp.UpperThresholdNonCritical,
+				temp.UpperThresholdCritical))
+
+		tables.temperatures.AddHighlightedRow(
+			severity,
+			chass.Name,
+			temp.Name,
+			formatReading(temp.ReadingCelsius, "C"),
+			formatOptional(temp.UpperThresholdNonCritical, "C"),
+			formatOptional(temp.UpperThresholdCritical, "C"),
+			formatOptional(temp.UpperThresholdFatal, "C"),
+			severity)
+	}
+
+	return nil
+}
+
+// addThermalSubsystem adds the details from the ThermalSubsystem and Sensors
+// resources used by newer services.
+func addThermalSubsystem(tables *thermalTables, chass *redfish.Chassis) error {
+	subsystem, err := chass.ThermalSubsystem()
+	if err != nil {
+		return err
+	}
+
+	if subsystem != nil {
+		fans, err := subsystem.Fans()
+		if err != nil {
+			return err
+		}
+
+		for _, fan := range fans {
+			reading := formatReading(float32(fan.SpeedPercent.Reading), "%")
+			if fan.SpeedPercent.SpeedRPM != 0 {
+				reading = formatReading(float32(fan.SpeedPercent.SpeedRPM), "RPM")
+			}
+
+			tables.fans.AddHighlightedRow(
+				utils.HealthSeverity(fan.Status.Health),
+				chass.Name,
+				fan.Name,
+				reading,
+				"",
+				fan.Status.Health)
+		}
+
+		for i := range subsystem.FanRedundancy {
+			group := &subsystem.FanRedundancy[i]
+			tables.redundancy.AddHighlightedRow(
+				utils.HealthSeverity(group.Status.Health),
+				chass.Name,
+				"Fans",
+				group.RedundancyType,
+				group.MinNeededInGroup,
+				group.Status.Health)
+		}
+	}
+
+	sensors, err := chass.Sensors()
+	if err != nil {
+		return err
+	}
+
+	for _, sensor := range sensors {
+		if sensor.ReadingType != redfish.TemperatureReadingType {
+			continue
+		}
+
+		severity := utils.SensorSeverity(sensor)
+		tables.temperatures.AddHighlightedRow(
+			severity,
+			chass.Name,
+			sensor.Name,
+			formatReading(sensor.Reading, "C"),
+			formatOptional(sensor.Thresholds.UpperCaution.Reading, "C"),
+			formatOptional(sensor.Thresholds.UpperCritical.Reading, "C"),
+			formatOptional(sensor.Thresholds.UpperFatal.Reading, "C"),
+			severity)
+	}
+
+	return nil
+}
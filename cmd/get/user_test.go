@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestGetUser(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "user")
+	if err != nil {
+		t.Fatalf("get user failed: %v", err)
+	}
+
+	for _, expected := range []string{"admin", "Administrator", "operator", "Operator"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+}
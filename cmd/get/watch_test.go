@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package get
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestWatchJSON(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	systemCmd.SetContext(ctx)
+	t.Cleanup(func() { systemCmd.SetContext(context.Background()) })
+
+	sessions := func() int {
+		collection, _ := server.Resource("/redfish/v1/SessionService/Sessions")
+		members, _ := collection["Members"].([]interface{})
+		return len(members)
+	}
+
+	// Part way through, there should still only be the one session
+	during := make(chan int, 1)
+	go func() {
+		time.Sleep(600 * time.Millisecond)
+		during <- sessions()
+		_ = server.Update("/redfish/v1/Systems/1", map[string]interface{}{"IndicatorLED": "Lit"})
+	}()
+
+	output, err := mockuptest.Run(t, Cmd(), "system", "--watch=200ms", "--watch-json")
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	if count := <-during; count != 1 {
+		t.Errorf("expected 1 session while watching, found %d", count)
+	}
+	if count := sessions(); count != 0 {
+		t.Errorf("expected the session to be logged out, found %d", count)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected the first poll and one change, got:\n%s", output)
+	}
+
+	for i, expected := range []struct {
+		led     string
+		changed []interface{}
+	}{{"Off", nil}, {"Lit", []interface{}{"led"}}} {
+		line := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(lines[i]), &line); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+
+		row, _ := line["row"].(map[string]interface{})
+		changed, _ := line["changed"].([]interface{})
+		if row["name"] != "web01" || row["led"] != expected.led || len(changed) != len(expected.changed) {
+			t.Errorf("unexpected line %d: %s", i, lines[i])
+		}
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	mockuptest.Start(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	systemCmd.SetContext(ctx)
+	t.Cleanup(func() { systemCmd.SetContext(context.Background()) })
+
+	// The interval following --watch is not taken as the system name
+	output, err := mockuptest.Run(t, Cmd(), "system", "web01", "--watch", "1s")
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	for _, expected := range []string{"Every 1s: get system web01", "web01", "Web server"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+
+	// An explicit interval that is the same as the default leaves the
+	// arguments alone
+	ctx, cancel = context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	systemCmd.SetContext(ctx)
+
+	output, err = mockuptest.Run(t, Cmd(), "system", "--watch=2s", "5s")
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	for _, expected := range []string{"Every 2s: get system 5s", "system '5s' was not found"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+}
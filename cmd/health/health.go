@@ -0,0 +1,341 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Cmd gets the health command.
+func Cmd() *cobra.Command {
+	healthCmd := &cobra.Command{
+		Use:   "health [CONNECTION_OR_GROUP...]",
+		Short: "Report components that are not healthy.",
+		Long: dedent.Dedent(`Report components that are not healthy, for the default connection or
+		the given connections and groups.
+
+		The Health and HealthRollup of systems, processors, memory, storage,
+		storage controllers, drives, chassis, power supplies, fans and managers
+		are checked. Only the components that are not OK are listed. A rollup
+		is only listed when none of the components below it explain it.
+
+		The exit code reflects the most severe result (0 OK, 1 Warning,
+		2 Critical), so this can be used as a monitoring health check. A
+		connection that cannot be checked is Critical. If the check cannot be
+		run at all, such as when given an unknown group, the exit code is 3.`),
+		RunE: checkHealth,
+	}
+	utils.MarkMonitoring(healthCmd)
+
+	return healthCmd
+}
+
+// checkHealth checks each connection and reports the components that are not
+// healthy.
+func checkHealth(cmd *cobra.Command, args []string) error {
+	connections, err := utils.Connections(args)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	reports := make([]*report, len(connections))
+	utils.ForEachConnection(connections, func(i int, connection string) {
+		reports[i] = checkConnection(connection)
+	})
+
+	headers := []string{"health", "component", "name", "message"}
+	if len(connections) > 1 {
+		headers = append([]string{"connection"}, headers...)
+	}
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), headers...)
+
+	worst := utils.SeverityOK
+	checked := 0
+	degraded := 0
+	for _, report := range reports {
+		checked += report.checked
+		for _, finding := range report.findings {
+			worst = utils.MaxSeverity(worst, finding.severity)
+			degraded++
+
+			row := []interface{}{finding.severity, finding.path, finding.name, finding.message}
+			if len(connections) > 1 {
+				row = append([]interface{}{report.connection}, row...)
+			}
+			writer.AddHighlightedRow(finding.severity, row...)
+		}
+	}
+
+	if degraded == 0 {
+		cmd.Printf("All %d components are OK.\n", checked)
+		return nil
+	}
+
+	writer.Render()
+	cmd.Printf("%d of %d components are not OK, overall health is %s.\n", degraded, checked, worst)
+	return utils.SeverityExit(cmd, worst)
+}
+
+// finding is a component that is not healthy.
+type finding struct {
+	severity utils.Severity
+	// path is the URI of the component below the service root.
+	path    string
+	name    string
+	message string
+	// rollupOnly is set if only the health of the components below it is
+	// degraded.
+	rollupOnly bool
+}
+
+// report is the result of checking one connection.
+type report struct {
+	connection string
+	checked    int
+	findings   []*finding
+}
+
+// checkConnection checks the health of everything on a connection.
+func checkConnection(connection string) *report {
+	result := &report{connection: connection}
+
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		result.findings = append(result.findings, &finding{
+			severity: utils.SeverityCritical, path: "/", message: err.Error()})
+		return result
+	}
+	defer c.Logout()
+
+	checker := &checker{
+		client: c, messages: utils.NewMessageRegistries(c), report: result, seen: map[string]bool{}}
+	root, err := utils.GetResource(c, utils.ServiceRoot)
+	if err != nil {
+		checker.failed(utils.ServiceRoot, err)
+		return result
+	}
+
+	checker.members(root, "Systems", checker.system)
+	checker.members(root, "Chassis", checker.chassis)
+	checker.members(root, "Managers", checker.check)
+
+	result.findings = explainRollups(result.findings)
+	sort.SliceStable(result.findings, func(i, j int) bool {
+		return result.findings[i].severity > result.findings[j].severity
+	})
+	return result
+}
+
+// checker walks the resources of a connection, checking their status.
+type checker struct {
+	client   common.Client
+	messages *utils.MessageRegistries
+	report   *report
+	seen     map[string]bool
+}
+
+// system checks a computer system and its processors, memory and storage.
+func (h *checker) system(system utils.Resource) {
+	h.check(system)
+	h.members(system, "Processors", h.check)
+	h.members(system, "Memory", h.check)
+	h.members(system, "Storage", h.storage)
+}
+
+// storage checks a storage subsystem, its controllers and its drives.
+// Controllers are either listed in the resource or in a collection.
+func (h *checker) storage(storage utils.Resource) {
+	h.check(storage)
+	h.embedded(storage, "StorageControllers")
+	h.members(storage, "Controllers", h.check)
+
+	drives, _ := storage["Drives"].([]interface{})
+	for _, drive := range drives {
+		if uri := utils.LinkURI(drive); uri != "" {
+			h.get(uri, h.check)
+		}
+	}
+}
+
+// chassis checks a chassis along with its power supplies and fans, from the
+// newer subsystem resources if present or the older Power and Thermal ones.
+func (h *checker) chassis(chassis utils.Resource) {
+	h.check(chassis)
+
+	if uri := chassis.Link("PowerSubsystem"); uri != "" {
+		h.get(uri, func(power utils.Resource) { h.members(power, "PowerSupplies", h.check) })
+	} else if uri := chassis.Link("Power"); uri != "" {
+		h.get(uri, func(power utils.Resource) { h.embedded(power, "PowerSupplies") })
+	}
+
+	if uri := chassis.Link("ThermalSubsystem"); uri != "" {
+		h.get(uri, func(thermal utils.Resource) { h.members(thermal, "Fans", h.check) })
+	} else if uri := chassis.Link("Thermal"); uri != "" {
+		h.get(uri, func(thermal utils.Resource) { h.embedded(thermal, "Fans") })
+	}
+}
+
+// members gets each member of a linked collection and passes it on.
+func (h *checker) members(resource utils.Resource, property string, fn func(utils.Resource)) {
+	uri := resource.Link(property)
+	if uri == "" {
+		return
+	}
+
+	collection, err := utils.GetAllPages(h.client, uri)
+	if err != nil {
+		h.failed(uri, err)
+		return
+	}
+
+	for _, member := range collection.Members() {
+		h.get(member, fn)
+	}
+}
+
+// get retrieves a resource and passes it on. Resources are only handled once,
+// as drives and other components can be linked from more than one place.
+func (h *checker) get(uri string, fn func(utils.Resource)) {
+	uri = strings.TrimSuffix(utils.ResourceURI(uri), "/")
+	if h.seen[uri] {
+		return
+	}
+	h.seen[uri] = true
+
+	resource, err := utils.GetResource(h.client, uri)
+	if err != nil {
+		h.failed(uri, err)
+		return
+	}
+
+	fn(resource)
+}
+
+// embedded checks the components listed in an array property of a resource,
+// such as the power supplies of a Power resource.
+func (h *checker) embedded(resource utils.Resource, property string) {
+	items, _ := resource[property].([]interface{})
+	for i, item := range items {
+		component, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if utils.Resource(component).ID() == "" {
+			component["@odata.id"] = fmt.Sprintf("%s#/%s/%d", resource.ID(), property, i)
+		}
+		h.check(component)
+	}
+}
+
+// check records the status of a component if it is not healthy. Components
+// that are not installed are skipped.
+func (h *checker) check(component utils.Resource) {
+	status, _ := component["Status"].(map[string]interface{})
+	if state, _ := status["State"].(string); state == string(common.AbsentState) {
+		return
+	}
+	h.report.checked++
+
+	health, _ := status["Health"].(string)
+	rollup, _ := status["HealthRollup"].(string)
+	healthSeverity := utils.HealthSeverity(common.Health(health))
+	rollupSeverity := utils.HealthSeverity(common.Health(rollup))
+	if healthSeverity == utils.SeverityOK && rollupSeverity == utils.SeverityOK {
+		return
+	}
+
+	messages := []string{}
+	if healthSeverity != utils.SeverityOK {
+		messages = append(messages, "Health is "+health)
+	}
+	if rollupSeverity != utils.SeverityOK && rollup != health {
+		messages = append(messages, "HealthRollup is "+rollup)
+	}
+	messages = append(messages, conditions(h.messages, status)...)
+
+	name, _ := component["Name"].(string)
+	h.report.findings = append(h.report.findings, &finding{
+		severity:   utils.MaxSeverity(healthSeverity, rollupSeverity),
+		path:       relativePath(component.ID()),
+		name:       name,
+		message:    strings.Join(messages, "; "),
+		rollupOnly: healthSeverity == utils.SeverityOK,
+	})
+}
+
+// failed records a resource that could not be retrieved. The service may be
+// missing something it should have, so it is reported as a warning.
+func (h *checker) failed(uri string, err error) {
+	h.report.findings = append(h.report.findings, &finding{
+		severity: utils.SeverityWarning,
+		path:     relativePath(uri),
+		message:  "unable to retrieve: " + utils.ErrorMessage(err),
+	})
+}
+
+// conditions gets the messages of any Conditions in a status, which newer
+// services use to explain what is wrong.
+func conditions(registries *utils.MessageRegistries, status map[string]interface{}) []string {
+	messages := []string{}
+	entries, _ := status["Conditions"].([]interface{})
+	for _, entry := range entries {
+		condition, _ := entry.(map[string]interface{})
+		messageID, _ := condition["MessageId"].(string)
+		message, _ := condition["Message"].(string)
+		args := []string{}
+		values, _ := condition["MessageArgs"].([]interface{})
+		for _, value := range values {
+			args = append(args, fmt.Sprint(value))
+		}
+
+		if text := registries.Message(messageID, message, args); text != "" {
+			messages = append(messages, text)
+		}
+	}
+
+	return messages
+}
+
+// explainRollups drops the findings that only have a degraded rollup when a
+// component below them is listed, as that already explains the rollup.
+func explainRollups(findings []*finding) []*finding {
+	result := []*finding{}
+	for _, candidate := range findings {
+		explained := false
+		if candidate.rollupOnly {
+			for _, other := range findings {
+				below := strings.HasPrefix(other.path, candidate.path+"/") ||
+					strings.HasPrefix(other.path, candidate.path+"#")
+				if other != candidate && below {
+					explained = true
+					break
+				}
+			}
+		}
+
+		if !explained {
+			result = append(result, candidate)
+		}
+	}
+
+	return result
+}
+
+// relativePath shortens a URI to its path below the service root.
+func relativePath(uri string) string {
+	path := strings.TrimPrefix(utils.ResourceURI(uri), utils.ServiceRoot)
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "/"
+	}
+
+	return path
+}
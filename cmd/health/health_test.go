@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package health
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup"
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// setStatus changes a status property of a mockup resource.
+func setStatus(t *testing.T, server *mockup.Server, uri, property, value string) {
+	t.Helper()
+
+	err := server.Update(uri, map[string]interface{}{
+		"Status": map[string]interface{}{property: value},
+	})
+	if err != nil {
+		t.Fatalf("unable to update %s: %v", uri, err)
+	}
+}
+
+func TestHealthOK(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd())
+	if err != nil {
+		t.Fatalf("health failed: %v", err)
+	}
+
+	if !strings.Contains(output, "All 10 components are OK.") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+}
+
+func TestHealthDegraded(t *testing.T) {
+	server := mockuptest.Start(t)
+	setStatus(t, server, "/redfish/v1/Systems/1/Processors/CPU2", "Health", "Critical")
+	setStatus(t, server, "/redfish/v1/Systems/1", "HealthRollup", "Critical")
+	setStatus(t, server, "/redfish/v1/Managers/bmc", "HealthRollup", "Warning")
+
+	output, err := mockuptest.Run(t, Cmd())
+
+	var exitErr *utils.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != int(utils.SeverityCritical) {
+		t.Errorf("expected a critical exit code, got %v", err)
+	}
+
+	for _, expected := range []string{"Systems/1/Processors/CPU2", "Managers/bmc", "HealthRollup is Warning", "2 of 10 components are not OK"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+
+	// The system rollup is explained by the processor
+	if strings.Contains(output, "Systems/1 ") {
+		t.Errorf("the explained system rollup should not be listed:\n%s", output)
+	}
+}
+
+func TestHealthUnknownGroup(t *testing.T) {
+	mockuptest.Start(t)
+
+	cmd := Cmd()
+	_, err := mockuptest.Run(t, cmd, "no-such-group")
+	if err == nil {
+		t.Fatal("expected an error for an unknown connection")
+	}
+
+	if code := utils.ExitCode(cmd, err); code != utils.ExitUnknown {
+		t.Errorf("expected the unknown exit code, got %d: %v", code, err)
+	}
+}
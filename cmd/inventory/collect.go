@@ -0,0 +1,341 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// inventory is the hardware of one connection.
+type inventory struct {
+	Connection string     `json:"connection" yaml:"connection"`
+	Collected  time.Time  `json:"collected" yaml:"collected"`
+	Systems    []*system  `json:"systems" yaml:"systems"`
+	Chassis    []*chassis `json:"chassis" yaml:"chassis"`
+	Managers   []*manager `json:"managers" yaml:"managers"`
+	// Errors lists the resources that could not be retrieved, so an
+	// incomplete inventory is not mistaken for a complete one.
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// identity is what identifies a piece of hardware for asset tracking.
+type identity struct {
+	ID           string `json:"id" yaml:"id"`
+	Name         string `json:"name,omitempty" yaml:"name,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty" yaml:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty" yaml:"model,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty" yaml:"serial_number,omitempty"`
+	PartNumber   string `json:"part_number,omitempty" yaml:"part_number,omitempty"`
+}
+
+func (i *identity) key() string {
+	return i.ID
+}
+
+// sortByID puts components in order, as services may list them in any order.
+func sortByID[T interface{ key() string }](items []T) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].key() < items[j].key() })
+}
+
+type system struct {
+	identity    `yaml:",inline"`
+	SKU         string        `json:"sku,omitempty" yaml:"sku,omitempty"`
+	UUID        string        `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	AssetTag    string        `json:"asset_tag,omitempty" yaml:"asset_tag,omitempty"`
+	BIOSVersion string        `json:"bios_version,omitempty" yaml:"bios_version,omitempty"`
+	Processors  []*processor  `json:"processors" yaml:"processors"`
+	Memory      []*memory     `json:"memory" yaml:"memory"`
+	Drives      []*drive      `json:"drives" yaml:"drives"`
+	NICs        []*nic        `json:"nics" yaml:"nics"`
+	PCIeDevices []*pcieDevice `json:"pcie_devices" yaml:"pcie_devices"`
+}
+
+type processor struct {
+	identity     `yaml:",inline"`
+	Socket       string  `json:"socket,omitempty" yaml:"socket,omitempty"`
+	Cores        int     `json:"cores,omitempty" yaml:"cores,omitempty"`
+	Threads      int     `json:"threads,omitempty" yaml:"threads,omitempty"`
+	MaxSpeedMHz  float32 `json:"max_speed_mhz,omitempty" yaml:"max_speed_mhz,omitempty"`
+	Architecture string  `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+}
+
+type memory struct {
+	identity    `yaml:",inline"`
+	Locator     string `json:"locator,omitempty" yaml:"locator,omitempty"`
+	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
+	CapacityMiB int    `json:"capacity_mib,omitempty" yaml:"capacity_mib,omitempty"`
+	SpeedMHz    int    `json:"speed_mhz,omitempty" yaml:"speed_mhz,omitempty"`
+}
+
+type drive struct {
+	identity      `yaml:",inline"`
+	MediaType     string `json:"media_type,omitempty" yaml:"media_type,omitempty"`
+	Protocol      string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	CapacityBytes int64  `json:"capacity_bytes,omitempty" yaml:"capacity_bytes,omitempty"`
+	Revision      string `json:"revision,omitempty" yaml:"revision,omitempty"`
+}
+
+type nic struct {
+	identity   `yaml:",inline"`
+	MACAddress string `json:"mac_address,omitempty" yaml:"mac_address,omitempty"`
+	// PermanentMACAddress is only set if it differs from the current address.
+	PermanentMACAddress string `json:"permanent_mac_address,omitempty" yaml:"permanent_mac_address,omitempty"`
+	SpeedMbps           int    `json:"speed_mbps,omitempty" yaml:"speed_mbps,omitempty"`
+}
+
+type pcieDevice struct {
+	identity        `yaml:",inline"`
+	Slot            string `json:"slot,omitempty" yaml:"slot,omitempty"`
+	FirmwareVersion string `json:"firmware_version,omitempty" yaml:"firmware_version,omitempty"`
+}
+
+type chassis struct {
+	identity      `yaml:",inline"`
+	SKU           string         `json:"sku,omitempty" yaml:"sku,omitempty"`
+	AssetTag      string         `json:"asset_tag,omitempty" yaml:"asset_tag,omitempty"`
+	Type          string         `json:"type,omitempty" yaml:"type,omitempty"`
+	PowerSupplies []*powerSupply `json:"power_supplies" yaml:"power_supplies"`
+}
+
+type powerSupply struct {
+	identity        `yaml:",inline"`
+	FirmwareVersion string  `json:"firmware_version,omitempty" yaml:"firmware_version,omitempty"`
+	CapacityWatts   float32 `json:"capacity_watts,omitempty" yaml:"capacity_watts,omitempty"`
+}
+
+type manager struct {
+	identity        `yaml:",inline"`
+	Type            string   `json:"type,omitempty" yaml:"type,omitempty"`
+	FirmwareVersion string   `json:"firmware_version,omitempty" yaml:"firmware_version,omitempty"`
+	MACAddresses    []string `json:"mac_addresses,omitempty" yaml:"mac_addresses,omitempty"`
+}
+
+// collectInventory gets the inventory of a connection. Only failing to
+// connect is an error, anything else that cannot be retrieved is listed in
+// the inventory.
+func collectInventory(connection, name string) (*inventory, error) {
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout()
+
+	result := &inventory{
+		Connection: name,
+		Collected:  time.Now().UTC().Truncate(time.Second),
+		Systems:    []*system{},
+		Chassis:    []*chassis{},
+		Managers:   []*manager{},
+	}
+
+	systems, err := c.Service.Systems()
+	result.failed("systems", err)
+	for _, s := range systems {
+		result.Systems = append(result.Systems, result.system(s))
+	}
+
+	allChassis, err := c.Service.Chassis()
+	result.failed("chassis", err)
+	for _, ch := range allChassis {
+		result.Chassis = append(result.Chassis, result.chassis(ch))
+	}
+
+	managers, err := c.Service.Managers()
+	result.failed("managers", err)
+	for _, m := range managers {
+		result.Managers = append(result.Managers, result.manager(m))
+	}
+
+	sortByID(result.Systems)
+	sortByID(result.Chassis)
+	sortByID(result.Managers)
+	return result, nil
+}
+
+// failed records something that could not be retrieved.
+func (inv *inventory) failed(what string, err error) {
+	if err != nil {
+		inv.Errors = append(inv.Errors, fmt.Sprintf("unable to get %s: %s", what, utils.ErrorMessage(err)))
+	}
+}
+
+// system gets a computer system along with its components.
+func (inv *inventory) system(s *redfish.ComputerSystem) *system {
+	result := &system{
+		identity:    identity{s.ID, s.Name, s.Manufacturer, s.Model, s.SerialNumber, s.PartNumber},
+		SKU:         s.SKU,
+		UUID:        s.UUID,
+		AssetTag:    s.AssetTag,
+		BIOSVersion: s.BIOSVersion,
+		Processors:  []*processor{},
+		Memory:      []*memory{},
+		Drives:      []*drive{},
+		NICs:        []*nic{},
+		PCIeDevices: []*pcieDevice{},
+	}
+	where := fmt.Sprintf("of system %s", s.ID)
+
+	processors, err := s.Processors()
+	inv.failed("processors "+where, err)
+	for _, p := range processors {
+		if installed(p.Status) {
+			result.Processors = append(result.Processors, &processor{
+				identity:     identity{p.ID, p.Name, p.Manufacturer, p.Model, p.SerialNumber, p.PartNumber},
+				Socket:       p.Socket,
+				Cores:        p.TotalCores,
+				Threads:      p.TotalThreads,
+				MaxSpeedMHz:  p.MaxSpeedMHz,
+				Architecture: string(p.ProcessorArchitecture),
+			})
+		}
+	}
+
+	modules, err := s.Memory()
+	inv.failed("memory "+where, err)
+	for _, m := range modules {
+		if installed(m.Status) {
+			result.Memory = append(result.Memory, &memory{
+				identity:    identity{m.ID, m.Name, m.Manufacturer, "", m.SerialNumber, m.PartNumber},
+				Locator:     m.DeviceLocator,
+				Type:        string(m.MemoryDeviceType),
+				CapacityMiB: m.CapacityMiB,
+				SpeedMHz:    m.OperatingSpeedMhz,
+			})
+		}
+	}
+
+	storage, err := s.Storage()
+	inv.failed("storage "+where, err)
+	for _, st := range storage {
+		drives, err := st.Drives()
+		inv.failed(fmt.Sprintf("drives of storage %s %s", st.ID, where), err)
+		for _, d := range drives {
+			if installed(d.Status) {
+				result.Drives = append(result.Drives, &drive{
+					identity:      identity{d.ID, d.Name, d.Manufacturer, d.Model, d.SerialNumber, d.PartNumber},
+					MediaType:     string(d.MediaType),
+					Protocol:      string(d.Protocol),
+					CapacityBytes: d.CapacityBytes,
+					Revision:      d.Revision,
+				})
+			}
+		}
+	}
+
+	interfaces, err := s.EthernetInterfaces()
+	inv.failed("network interfaces "+where, err)
+	for _, e := range interfaces {
+		permanent := e.PermanentMACAddress
+		if permanent == e.MACAddress {
+			permanent = ""
+		}
+		result.NICs = append(result.NICs, &nic{
+			identity:            identity{ID: e.ID, Name: e.Name},
+			MACAddress:          e.MACAddress,
+			PermanentMACAddress: permanent,
+			SpeedMbps:           e.SpeedMbps,
+		})
+	}
+
+	devices, err := s.PCIeDevices()
+	inv.failed("PCIe devices "+where, err)
+	for _, d := range devices {
+		if installed(d.Status) {
+			result.PCIeDevices = append(result.PCIeDevices, &pcieDevice{
+				identity:        identity{d.ID, d.Name, d.Manufacturer, d.Model, d.SerialNumber, d.PartNumber},
+				Slot:            d.Slot.Location.PartLocation.ServiceLabel,
+				FirmwareVersion: d.FirmwareVersion,
+			})
+		}
+	}
+
+	sortByID(result.Processors)
+	sortByID(result.Memory)
+	sortByID(result.Drives)
+	sortByID(result.NICs)
+	sortByID(result.PCIeDevices)
+	return result
+}
+
+// chassis gets a chassis and its power supplies, from the newer power
+// subsystem if there is no Power resource.
+func (inv *inventory) chassis(ch *redfish.Chassis) *chassis {
+	result := &chassis{
+		identity:      identity{ch.ID, ch.Name, ch.Manufacturer, ch.Model, ch.SerialNumber, ch.PartNumber},
+		SKU:           ch.SKU,
+		AssetTag:      ch.AssetTag,
+		Type:          string(ch.ChassisType),
+		PowerSupplies: []*powerSupply{},
+	}
+	where := fmt.Sprintf("of chassis %s", ch.ID)
+
+	power, err := ch.Power()
+	inv.failed("power "+where, err)
+	if power != nil {
+		for i := range power.PowerSupplies {
+			p := &power.PowerSupplies[i]
+			if installed(p.Status) {
+				result.PowerSupplies = append(result.PowerSupplies, &powerSupply{
+					identity:        identity{p.MemberID, p.Name, p.Manufacturer, p.Model, p.SerialNumber, p.PartNumber},
+					FirmwareVersion: p.FirmwareVersion,
+					CapacityWatts:   p.PowerCapacityWatts,
+				})
+			}
+		}
+		return result
+	}
+
+	subsystem, err := ch.PowerSubsystem()
+	inv.failed("power subsystem "+where, err)
+	if subsystem == nil {
+		return result
+	}
+
+	supplies, err := subsystem.PowerSupplies()
+	inv.failed("power supplies "+where, err)
+	for _, supply := range supplies {
+		unit, err := redfish.GetPowerSupplyUnit(supply.GetClient(), supply.ODataID)
+		inv.failed("power supply "+supply.ID+" "+where, err)
+		if err == nil && installed(unit.Status) {
+			result.PowerSupplies = append(result.PowerSupplies, &powerSupply{
+				identity:        identity{unit.ID, unit.Name, unit.Manufacturer, unit.Model, unit.SerialNumber, unit.PartNumber},
+				FirmwareVersion: unit.FirmwareVersion,
+				CapacityWatts:   unit.PowerCapacityWatts,
+			})
+		}
+	}
+
+	sortByID(result.PowerSupplies)
+	return result
+}
+
+// manager gets a manager, usually the BMC, and the MAC addresses it uses.
+func (inv *inventory) manager(m *redfish.Manager) *manager {
+	result := &manager{
+		identity:        identity{ID: m.ID, Name: m.Name, Manufacturer: m.Manufacturer, Model: m.Model, SerialNumber: m.SerialNumber, PartNumber: m.PartNumber},
+		Type:            string(m.ManagerType),
+		FirmwareVersion: m.FirmwareVersion,
+	}
+
+	interfaces, err := m.EthernetInterfaces()
+	inv.failed(fmt.Sprintf("network interfaces of manager %s", m.ID), err)
+	for _, e := range interfaces {
+		if e.MACAddress != "" {
+			result.MACAddresses = append(result.MACAddresses, e.MACAddress)
+		}
+	}
+
+	return result
+}
+
+// installed checks that a component is present, as empty slots are often
+// listed too.
+func installed(status common.Status) bool {
+	return status.State != common.AbsentState
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package inventory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvHeaders are the columns of the CSV inventory, which has a row for each
+// component so it can be imported into an asset database.
+var csvHeaders = []string{
+	"connection", "type", "parent", "id", "name", "manufacturer", "model", "serial_number", "part_number",
+	"sku", "uuid", "asset_tag", "location", "version", "capacity", "mac_address",
+}
+
+// csvRow is a component in the CSV inventory. Only the columns that apply to
+// the component are set.
+type csvRow struct {
+	identity
+	kind, parent, sku, uuid, assetTag, location, version, capacity, mac string
+}
+
+func (r *csvRow) values(connection string) []string {
+	return []string{
+		connection, r.kind, r.parent, r.ID, r.Name, r.Manufacturer, r.Model, r.SerialNumber, r.PartNumber,
+		r.sku, r.uuid, r.assetTag, r.location, r.version, r.capacity, r.mac,
+	}
+}
+
+// writeCSV writes the components of the inventories as CSV.
+func writeCSV(w io.Writer, inventories []*inventory) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvHeaders)
+	if err != nil {
+		return err
+	}
+
+	for _, inv := range inventories {
+		for _, row := range inv.rows() {
+			err = writer.Write(row.values(inv.Connection))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// rows flattens an inventory into a row for each component. Components are
+// related to their system or chassis through the parent column.
+func (inv *inventory) rows() []*csvRow {
+	rows := []*csvRow{}
+	for _, s := range inv.Systems {
+		rows = append(rows, &csvRow{
+			identity: s.identity, kind: "system", sku: s.SKU, uuid: s.UUID, assetTag: s.AssetTag, version: s.BIOSVersion,
+		})
+		parent := "system/" + s.ID
+
+		for _, p := range s.Processors {
+			rows = append(rows, &csvRow{
+				identity: p.identity, kind: "processor", parent: parent, location: p.Socket,
+				capacity: amount(p.Cores, "cores"),
+			})
+		}
+
+		for _, m := range s.Memory {
+			rows = append(rows, &csvRow{
+				identity: m.identity, kind: "memory", parent: parent, location: m.Locator,
+				capacity: amount(m.CapacityMiB, "MiB"),
+			})
+		}
+
+		for _, d := range s.Drives {
+			rows = append(rows, &csvRow{
+				identity: d.identity, kind: "drive", parent: parent, version: d.Revision,
+				capacity: amount(d.CapacityBytes, "bytes"),
+			})
+		}
+
+		for _, n := range s.NICs {
+			rows = append(rows, &csvRow{identity: n.identity, kind: "nic", parent: parent, mac: n.MACAddress})
+		}
+
+		for _, d := range s.PCIeDevices {
+			rows = append(rows, &csvRow{
+				identity: d.identity, kind: "pcie_device", parent: parent, location: d.Slot, version: d.FirmwareVersion,
+			})
+		}
+	}
+
+	for _, ch := range inv.Chassis {
+		rows = append(rows, &csvRow{identity: ch.identity, kind: "chassis", sku: ch.SKU, assetTag: ch.AssetTag})
+
+		for _, p := range ch.PowerSupplies {
+			rows = append(rows, &csvRow{
+				identity: p.identity, kind: "power_supply", parent: "chassis/" + ch.ID, version: p.FirmwareVersion,
+				capacity: amount(p.CapacityWatts, "W"),
+			})
+		}
+	}
+
+	for _, m := range inv.Managers {
+		rows = append(rows, &csvRow{
+			identity: m.identity, kind: "manager", version: m.FirmwareVersion, mac: strings.Join(m.MACAddresses, " "),
+		})
+	}
+
+	return rows
+}
+
+// amount formats a quantity with its unit, leaving it blank if unknown.
+func amount[T int | int64 | float32](value T, unit string) string {
+	if value == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%v %s", value, unit)
+}
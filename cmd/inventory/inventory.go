@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// formats are the supported output formats.
+var formats = []string{"json", "yaml", "csv"}
+
+// combinedFile is the name of the CSV of every connection written with --dir.
+const combinedFile = "inventory.csv"
+
+// Cmd gets the inventory command.
+func Cmd() *cobra.Command {
+	inventoryCmd := &cobra.Command{
+		Use:   "inventory [CONNECTION_OR_GROUP...]",
+		Short: "Export the hardware inventory.",
+		Long: dedent.Dedent(`Export the hardware inventory of the default connection or the given
+		connections and groups.
+
+		The inventory lists the identity of each system (manufacturer, model,
+		SKU, serial number, UUID and asset tag) and BIOS version, its
+		processors, memory, drives, network interfaces with their MAC
+		addresses and PCIe devices, the power supplies of each chassis, and
+		the firmware version of each manager. Empty slots are left out.
+
+		The inventory is written as JSON, YAML or CSV, with one row per
+		component in CSV. With --dir, an inventory file is written for each
+		connection along with a combined inventory.csv of all of them. Anything
+		that could not be retrieved is listed under errors rather than
+		stopping the export.`),
+		RunE: exportInventory,
+	}
+
+	inventoryCmd.Flags().String("format", "",
+		fmt.Sprintf("Output format (%s). Defaults to the extension of --output, or json.", strings.Join(formats, ", ")))
+	inventoryCmd.Flags().StringP("output", "o", "", "File to write the inventory to instead of stdout.")
+	inventoryCmd.Flags().String("dir", "",
+		"Directory to write a file for each connection and a combined "+combinedFile+" to.")
+	inventoryCmd.MarkFlagsMutuallyExclusive("output", "dir")
+	inventoryCmd.Flags().SortFlags = true
+
+	return inventoryCmd
+}
+
+// exportInventory collects the inventory of each connection and writes it out.
+func exportInventory(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	dir, _ := cmd.Flags().GetString("dir")
+	format, err := outputFormat(cmd, output)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	connections, err := utils.Connections(args)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	inventories := make([]*inventory, len(connections))
+	failures := make([]error, len(connections))
+	utils.ForEachConnection(connections, func(i int, connection string) {
+		inventories[i], failures[i] = collectInventory(connection, utils.ConnectionName(connection))
+	})
+
+	collected := []*inventory{}
+	failed := 0
+	for i, inv := range inventories {
+		if failures[i] != nil {
+			cmd.PrintErrf("Unable to collect the inventory of %s: %v\n", utils.ConnectionName(connections[i]), failures[i])
+			failed++
+			continue
+		}
+		collected = append(collected, inv)
+	}
+
+	if dir != "" {
+		err = writeFleet(cmd, dir, format, collected)
+	} else {
+		err = writeOutput(cmd, output, func(w io.Writer) error {
+			return write(w, format, collected, len(connections) > 1)
+		})
+	}
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	if failed > 0 {
+		return utils.ErrorExit(cmd, "unable to collect the inventory of %d of %d connections", failed, len(connections))
+	}
+
+	return nil
+}
+
+// outputFormat gets the requested format, falling back to the extension of
+// the output file.
+func outputFormat(cmd *cobra.Command, output string) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(output), ".")
+		if format == "yml" {
+			format = "yaml"
+		}
+		if !validFormat(format) {
+			format = "json"
+		}
+	}
+
+	format = strings.ToLower(format)
+	if !validFormat(format) {
+		return "", utils.Error("invalid format '%s', must be one of: %s", format, strings.Join(formats, ", "))
+	}
+
+	return format, nil
+}
+
+func validFormat(format string) bool {
+	for _, f := range formats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write outputs inventories in a format. Several inventories are written as a
+// list, or as the rows of one CSV.
+func write(w io.Writer, format string, inventories []*inventory, list bool) error {
+	var document interface{} = inventories
+	if !list && len(inventories) == 1 {
+		document = inventories[0]
+	}
+
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "csv":
+		return writeCSV(w, inventories)
+	default:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(document)
+	}
+}
+
+// writeOutput writes to the output file, or stdout if there is none.
+func writeOutput(cmd *cobra.Command, output string, fn func(io.Writer) error) error {
+	if output == "" {
+		return fn(cmd.OutOrStdout())
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	err = fn(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// writeFleet writes a file for each inventory to a directory, along with a
+// CSV of all of them.
+func writeFleet(cmd *cobra.Command, dir, format string, inventories []*inventory) error {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+
+	for _, inv := range inventories {
+		name := filepath.Join(dir, fileName(inv.Connection)+"."+format)
+		err = writeOutput(cmd, name, func(w io.Writer) error {
+			return write(w, format, []*inventory{inv}, false)
+		})
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Wrote %s\n", name)
+	}
+
+	name := filepath.Join(dir, combinedFile)
+	err = writeOutput(cmd, name, func(w io.Writer) error {
+		return writeCSV(w, inventories)
+	})
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Wrote %s\n", name)
+
+	return nil
+}
+
+// fileName makes a connection name safe to use as a file name.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
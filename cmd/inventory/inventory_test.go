@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package inventory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestInventoryJSON(t *testing.T) {
+	mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd())
+	if err != nil {
+		t.Fatalf("inventory failed: %v", err)
+	}
+
+	inv := &inventory{}
+	if err := json.Unmarshal([]byte(output), inv); err != nil {
+		t.Fatalf("output is not an inventory: %v\n%s", err, output)
+	}
+
+	if inv.Connection != "mockup" || len(inv.Errors) != 0 || len(inv.Systems) != 1 {
+		t.Fatalf("unexpected inventory:\n%s", output)
+	}
+
+	s := inv.Systems[0]
+	if s.SerialNumber != "437XR1138R2" || s.UUID == "" || s.AssetTag != "ASSET-0001" || s.BIOSVersion == "" {
+		t.Errorf("system identity is incomplete: %+v", s)
+	}
+
+	// The empty DIMM slot is left out
+	if len(s.Processors) != 2 || len(s.Memory) != 2 || s.Memory[0].ID != "DIMM1" {
+		t.Errorf("expected 2 processors and 2 DIMMs:\n%s", output)
+	}
+
+	if len(s.NICs) != 1 || s.NICs[0].MACAddress != "12:44:6A:3B:04:11" {
+		t.Errorf("expected the NIC MAC address:\n%s", output)
+	}
+
+	if len(inv.Chassis) != 1 || len(inv.Chassis[0].PowerSupplies) != 1 || inv.Chassis[0].PowerSupplies[0].SerialNumber != "1Z0000001" {
+		t.Errorf("expected the power supply:\n%s", output)
+	}
+
+	if len(inv.Managers) != 1 || inv.Managers[0].FirmwareVersion != "1.00" {
+		t.Errorf("expected the BMC firmware version:\n%s", output)
+	}
+}
+
+func TestInventoryDir(t *testing.T) {
+	mockuptest.Start(t)
+	dir := t.TempDir()
+
+	_, err := mockuptest.Run(t, Cmd(), "mockup", "--dir", dir, "--format", "yaml")
+	if err != nil {
+		t.Fatalf("inventory failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "mockup.yaml"))
+	if err != nil || !strings.Contains(string(data), "serial_number: 437XR1138R2") {
+		t.Errorf("expected the inventory of the connection, got %v:\n%s", err, data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, combinedFile))
+	if err != nil {
+		t.Fatalf("expected the combined CSV: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != strings.Join(csvHeaders, ",") {
+		t.Errorf("unexpected CSV headers: %s", lines[0])
+	}
+	for _, expected := range []string{
+		"mockup,memory,system/1,DIMM1,DIMM Slot 1,Contoso Memory,,3A5B0001,M393A8G40MB2,,,,PROC 1 DIMM 1,,49152 MiB,",
+		"mockup,nic,system/1,NIC1,Ethernet Interface,,,,,,,,,,,12:44:6A:3B:04:11",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("CSV is missing %q:\n%s", expected, data)
+		}
+	}
+}
+
+func TestInventoryFormat(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "--format", "xml")
+	if err == nil || !strings.Contains(err.Error(), "invalid format 'xml'") {
+		t.Errorf("expected invalid format error, got: %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// exportWorkers is the number of resources fetched at the same time.
+const exportWorkers = 8
+
+var exportCmd = &cobra.Command{
+	Use:   "export DIR",
+	Short: "Save the resources of a service as a mockup.",
+	Long: dedent.Dedent(`Save the resources of a service as a mockup, using the directory layout of
+	the DMTF Redfish-Mockup-Creator. Each resource is written to an index.json
+	file in a directory matching its URI, such as DIR/redfish/v1/Systems/1/index.json.
+
+	All links below /redfish/v1 are followed. Paged collections are saved with
+	all of their members. Properties holding credentials are redacted by default,
+	use --redact secrets,serials to also redact serial numbers, UUIDs and MAC
+	addresses.`),
+	RunE: exportMockup,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	exportCmd.Flags().Int("depth", 0, "Number of links to follow from the service root, 0 for no limit.")
+	exportCmd.Flags().StringSlice("redact", []string{"secrets"}, "Kinds of properties to redact (secrets, serials, none).")
+	exportCmd.Flags().Bool("force", false, "Write the mockup even if DIR is not empty.")
+	exportCmd.Flags().SortFlags = true
+}
+
+// exporter writes resources to a mockup directory.
+type exporter struct {
+	client   common.Client
+	dir      string
+	redact   []func(string) bool
+	errOut   io.Writer
+	mu       sync.Mutex
+	failures int
+}
+
+// exportMockup crawls the service and writes each resource.
+func exportMockup(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+
+	depth, _ := cmd.Flags().GetInt("depth")
+	if depth < 0 {
+		return utils.ErrorExit(cmd, "depth must not be negative")
+	}
+
+	redactions, _ := cmd.Flags().GetStringSlice("redact")
+	redact, err := redactFuncs(redactions)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !force {
+		return utils.ErrorExit(cmd, "directory '%s' is not empty, use --force to write to it anyway", dir)
+	}
+
+	// Replayed exports have no connection settings to describe the service
+	connection, _ := cmd.Flags().GetString("connection")
+	service := "recording " + utils.Options.ReplayFile
+	if utils.Options.ReplayFile == "" {
+		settings, err := utils.SystemSettings(connection)
+		if err != nil {
+			return utils.ErrorExit(cmd, "%v", err)
+		}
+		service = fmt.Sprintf("%s://%s:%d", settings.Protocol, settings.Host, settings.Port)
+	}
+
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	e := &exporter{client: c, dir: dir, redact: redact, errOut: cmd.ErrOrStderr()}
+	links, err := e.export(utils.ServiceRoot)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to export the service root: %v", err)
+	}
+
+	count := e.crawl(links, depth)
+	e.exportExtras()
+
+	err = e.writeReadme(service, depth, redactions)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	cmd.Printf("Exported %d resources to %s.\n", count-e.failures, dir)
+	if e.failures > 0 {
+		return utils.ErrorExit(cmd, "%d resources could not be exported", e.failures)
+	}
+
+	return nil
+}
+
+// redactFuncs gets the property matchers for the kinds of properties to
+// redact.
+func redactFuncs(kinds []string) ([]func(string) bool, error) {
+	result := []func(string) bool{}
+	for _, kind := range kinds {
+		switch strings.ToLower(kind) {
+		case "secrets":
+			result = append(result, utils.IsSecretProperty)
+		case "serials":
+			result = append(result, utils.IsSerialProperty)
+		case "none", "":
+		default:
+			return nil, utils.Error("invalid redact value '%s', must be secrets, serials or none", kind)
+		}
+	}
+
+	return result, nil
+}
+
+// crawl exports the resources linked from the service root one level at a
+// time, and returns the number of resources visited.
+func (e *exporter) crawl(rootLinks []utils.ResourceLink, depth int) int {
+	visited := map[string]bool{utils.ServiceRoot: true}
+	level := e.newLinks(rootLinks, visited)
+	count := 1
+
+	for current := 1; len(level) > 0 && (depth == 0 || current <= depth); current++ {
+		links := make([][]utils.ResourceLink, len(level))
+
+		var wg sync.WaitGroup
+		workers := make(chan struct{}, exportWorkers)
+		for i, uri := range level {
+			wg.Add(1)
+			workers <- struct{}{}
+			go func(i int, uri string) {
+				defer func() {
+					<-workers
+					wg.Done()
+				}()
+				links[i], _ = e.export(uri)
+			}(i, uri)
+		}
+		wg.Wait()
+
+		count += len(level)
+		level = []string{}
+		for _, found := range links {
+			level = append(level, e.newLinks(found, visited)...)
+		}
+	}
+
+	return count
+}
+
+// newLinks gets the URIs of the links that still need to be exported.
+func (e *exporter) newLinks(links []utils.ResourceLink, visited map[string]bool) []string {
+	result := []string{}
+	for _, link := range links {
+		if !strings.HasPrefix(link.URI, utils.ServiceRoot+"/") || strings.Contains(link.URI, "?") || visited[link.URI] {
+			continue
+		}
+
+		visited[link.URI] = true
+		result = append(result, link.URI)
+	}
+
+	return result
+}
+
+// export writes a single resource and returns the links it contains. Failures
+// are reported as warnings so the rest of the service can still be exported.
+func (e *exporter) export(uri string) ([]utils.ResourceLink, error) {
+	resource, err := utils.GetAllPages(e.client, uri)
+	if err == nil {
+		for _, redact := range e.redact {
+			utils.RedactProperties(map[string]interface{}(resource), redact)
+		}
+		err = e.writeJSON(uri, resource)
+	}
+
+	if err != nil {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.failures++
+		fmt.Fprintf(e.errOut, "Warning: unable to export %s: %s\n", uri, utils.ErrorMessage(err))
+		return nil, err
+	}
+
+	return resource.Links(), nil
+}
+
+// exportExtras writes the resources the Mockup Creator includes that are not
+// linked from the service. Not all services have them, so failures are
+// ignored.
+func (e *exporter) exportExtras() {
+	for _, uri := range []string{"/redfish", utils.ServiceRoot + "/odata"} {
+		if resource, err := utils.GetResource(e.client, uri); err == nil {
+			_ = e.writeJSON(uri, resource)
+		}
+	}
+
+	uri := utils.ServiceRoot + "/$metadata"
+	resp, err := e.client.GetWithHeaders(uri, map[string]string{"Accept": "application/xml"})
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err == nil {
+		_ = e.writeFile(uri, "index.xml", data)
+	}
+}
+
+// writeJSON writes a resource to its index.json file.
+func (e *exporter) writeJSON(uri string, resource utils.Resource) error {
+	data, err := json.MarshalIndent(resource, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return e.writeFile(uri, "index.json", append(data, '\n'))
+}
+
+// writeFile writes a file to the directory for a URI.
+func (e *exporter) writeFile(uri, name string, data []byte) error {
+	// Cleaning the rooted path keeps it from escaping the mockup directory
+	dir := filepath.Join(e.dir, filepath.FromSlash(path.Clean("/"+uri)))
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, name), data, 0o600)
+}
+
+// writeReadme records where and when the mockup was made.
+func (e *exporter) writeReadme(service string, depth int, redactions []string) error {
+	limit := "none"
+	if depth > 0 {
+		limit = fmt.Sprint(depth)
+	}
+
+	readme := fmt.Sprintf(
+		"Redfish mockup created by ctlfish\nService: %s\nTime: %s\nDepth limit: %s\nRedacted: %s\n",
+		service, time.Now().Format(time.RFC3339), limit, strings.Join(redactions, ", "))
+
+	return os.WriteFile(filepath.Join(e.dir, "README"), []byte(readme), 0o600)
+}
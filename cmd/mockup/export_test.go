@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup"
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestExport(t *testing.T) {
+	server := mockuptest.Start(t)
+	err := server.Update("/redfish/v1/AccountService", map[string]interface{}{
+		"LDAP": map[string]interface{}{
+			"Authentication": map[string]interface{}{"Password": "hunter2"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	output, err := mockuptest.Run(t, Cmd(), "export", dir, "--redact", "secrets,serials")
+	if err != nil {
+		t.Fatalf("export failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Exported ") {
+		t.Errorf("unexpected output:\n%s", output)
+	}
+
+	for _, file := range []string{
+		"README",
+		"redfish/index.json",
+		"redfish/v1/index.json",
+		"redfish/v1/Systems/1/index.json",
+		"redfish/v1/Systems/1/Memory/DIMM1/index.json",
+		"redfish/v1/Chassis/1/Power/index.json",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file))); err != nil {
+			t.Errorf("expected %s to be exported: %v", file, err)
+		}
+	}
+
+	exported, err := mockup.Load(dir)
+	if err != nil {
+		t.Fatalf("unable to load the exported mockup: %v", err)
+	}
+
+	system, _ := exported.Resource("/redfish/v1/Systems/1")
+	for _, property := range []string{"SerialNumber", "UUID"} {
+		if system[property] != utils.Redacted {
+			t.Errorf("expected %s to be redacted, got %v", property, system[property])
+		}
+	}
+	if system["Model"] != "3500" {
+		t.Errorf("expected the other properties to be kept, got model %v", system["Model"])
+	}
+
+	nic, _ := exported.Resource("/redfish/v1/Systems/1/EthernetInterfaces/NIC1")
+	if nic["MACAddress"] != utils.Redacted {
+		t.Errorf("expected the MAC address to be redacted, got %v", nic["MACAddress"])
+	}
+
+	accounts, _ := exported.Resource("/redfish/v1/AccountService")
+	ldap, _ := accounts["LDAP"].(map[string]interface{})
+	authentication, _ := ldap["Authentication"].(map[string]interface{})
+	if authentication["Password"] != utils.Redacted {
+		t.Errorf("expected the LDAP password to be redacted, got %v", authentication["Password"])
+	}
+}
+
+func TestExportDepth(t *testing.T) {
+	mockuptest.Start(t)
+
+	dir := t.TempDir()
+	output, err := mockuptest.Run(t, Cmd(), "export", dir, "--depth", "1")
+	if err != nil {
+		t.Fatalf("export failed: %v\n%s", err, output)
+	}
+
+	exported, err := mockup.Load(dir)
+	if err != nil {
+		t.Fatalf("unable to load the exported mockup: %v", err)
+	}
+
+	if _, found := exported.Resource("/redfish/v1/Systems"); !found {
+		t.Error("expected the systems linked from the service root to be exported")
+	}
+	if _, found := exported.Resource("/redfish/v1/Systems/1"); found {
+		t.Error("expected the crawl to stop after one level")
+	}
+}
@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	mockupCmd := &cobra.Command{
+		Use:   "mockup",
+		Short: "Work with offline copies of Redfish services.",
+	}
+
+	mockupCmd.AddCommand(exportCmd)
+	mockupCmd.AddCommand(serveCmd)
+
+	return mockupCmd
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/mockup"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve DIR",
+	Short: "Serve a mockup as a Redfish service.",
+	Long: dedent.Dedent(`Serve a mockup directory as a Redfish service, until interrupted.
+
+	The mockup can be one saved with "ctlfish mockup export" or by the DMTF
+	Redfish-Mockup-Creator. Sessions can be created and resources can be changed
+	with PATCH requests. Reset actions update the PowerState of the resource.
+	Changes are only kept in memory, the mockup files are never modified.`),
+	RunE: serveMockup,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	serveCmd.Flags().String("listen", "127.0.0.1:8000", "Address to listen on.")
+	serveCmd.Flags().String("username", "", "User name to accept. Any credentials are accepted if not set.")
+	serveCmd.Flags().String("password", "", "Password to accept with --username.")
+	serveCmd.Flags().Bool("tls", false, "Serve HTTPS using a self-signed certificate.")
+	serveCmd.Flags().Bool("log-requests", false, "Print each request that is handled.")
+	serveCmd.Flags().SortFlags = true
+}
+
+// serveMockup runs the mockup service.
+func serveMockup(cmd *cobra.Command, args []string) error {
+	server, err := mockup.Load(args[0])
+	if err != nil {
+		return utils.ErrorExit(cmd, "unable to load mockup: %v", err)
+	}
+	server.Username, _ = cmd.Flags().GetString("username")
+	server.Password, _ = cmd.Flags().GetString("password")
+
+	var handler http.Handler = server
+	if logRequests, _ := cmd.Flags().GetBool("log-requests"); logRequests {
+		handler = requestLogger(cmd, server)
+	}
+
+	address, _ := cmd.Flags().GetString("listen")
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return utils.ErrorExit(cmd, "unable to listen on '%s': %v", address, err)
+	}
+
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	protocol := "http"
+	if useTLS, _ := cmd.Flags().GetBool("tls"); useTLS {
+		host, _, _ := net.SplitHostPort(listener.Addr().String())
+		certificate, err := utils.SelfSignedCertificate(host, "localhost")
+		if err != nil {
+			return utils.ErrorExit(cmd, "unable to create certificate: %v", err)
+		}
+
+		httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+		listener = tls.NewListener(listener, httpServer.TLSConfig)
+		protocol = "https"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Close()
+	}()
+
+	cmd.PrintErrf("Serving %s on %s://%s, press Ctrl+C to stop.\n", args[0], protocol, listener.Addr())
+	err = httpServer.Serve(listener)
+	if !errors.Is(err, http.ErrServerClosed) {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	return nil
+}
+
+// statusRecorder keeps the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// requestLogger prints each request along with its response status.
+func requestLogger(cmd *cobra.Command, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		cmd.PrintErrf("%s %s %s %d\n", time.Now().Format(time.RFC3339), r.Method, r.URL.RequestURI(), recorder.status)
+	})
+}
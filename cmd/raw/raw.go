@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package raw
+
+import (
+	"net/http"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	rawCmd := &cobra.Command{
+		Use:   "raw",
+		Short: "Send raw Redfish requests.",
+		Long: dedent.Dedent(`Send requests to any Redfish URI using the saved connection settings.
+
+		URIs may be given in full (/redfish/v1/Systems/1), relative to the service
+		root (Systems/1), or as a complete URL.`),
+	}
+
+	rawCmd.AddCommand(newRequestCmd(http.MethodDelete, false))
+	rawCmd.AddCommand(newRequestCmd(http.MethodGet, false))
+	rawCmd.AddCommand(newRequestCmd(http.MethodPatch, true))
+	rawCmd.AddCommand(newRequestCmd(http.MethodPost, true))
+	rawCmd.AddCommand(newRequestCmd(http.MethodPut, true))
+
+	return rawCmd
+}
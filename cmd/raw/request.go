@@ -0,0 +1,243 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package raw
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// newRequestCmd creates the command to send a request with the given method.
+func newRequestCmd(method string, hasBody bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   strings.ToLower(method) + " URI",
+		Short: fmt.Sprintf("Send a %s request.", method),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sendRequest(cmd, method, args[0])
+		},
+	}
+
+	cmd.Flags().BoolP("include", "i", false, "Include the response status and headers in the output.")
+	cmd.Flags().StringArrayP("header", "H", []string{}, "Extra request header as 'Name: value'. May be repeated.")
+	if hasBody {
+		cmd.Flags().StringP("data", "d", "", "JSON request body. Use @FILE to read it from a file or - to read it from stdin.")
+	}
+	if method != http.MethodGet {
+		cmd.Flags().String("if-match", "", "Only apply the change if the resource ETag matches. Use 'auto' to use the current ETag.")
+	}
+	cmd.Flags().SortFlags = true
+
+	return cmd
+}
+
+// sendRequest sends the request and prints the response.
+func sendRequest(cmd *cobra.Command, method, uri string) error {
+	uri = utils.ResourceURI(uri)
+
+	headers, err := requestHeaders(cmd)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	var body json.RawMessage
+	if cmd.Flags().Lookup("data") != nil {
+		body, err = requestBody(cmd)
+		if err != nil {
+			return utils.ErrorExit(cmd, "%v", err)
+		}
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	if ifMatch, _ := cmd.Flags().GetString("if-match"); ifMatch != "" {
+		if ifMatch == "auto" {
+			ifMatch, err = currentETag(c, uri)
+			if err != nil {
+				return utils.ErrorExit(cmd, "unable to get the ETag of '%s': %v", uri, err)
+			}
+		}
+		headers["If-Match"] = ifMatch
+	}
+
+	var resp *http.Response
+	switch method {
+	case http.MethodGet:
+		resp, err = c.GetWithHeaders(uri, headers)
+	case http.MethodDelete:
+		resp, err = c.DeleteWithHeaders(uri, headers)
+	case http.MethodPatch:
+		resp, err = c.PatchWithHeaders(uri, body, headers)
+	case http.MethodPost:
+		resp, err = c.PostWithHeaders(uri, body, headers)
+	case http.MethodPut:
+		resp, err = c.PutWithHeaders(uri, body, headers)
+	}
+
+	include, _ := cmd.Flags().GetBool("include")
+	var rfErr *common.Error
+	if errors.As(err, &rfErr) {
+		// Show what the service said before reporting the failure
+		fmt.Fprintf(statusWriter(cmd, include), "HTTP %d %s\n", rfErr.HTTPReturnedStatusCode, http.StatusText(rfErr.HTTPReturnedStatusCode))
+		printBody(cmd.OutOrStdout(), errorBody(rfErr))
+		return utils.ErrorExit(cmd, "request failed: %v", err)
+	} else if err != nil {
+		return utils.ErrorExit(cmd, "request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	fmt.Fprintf(statusWriter(cmd, include), "HTTP %s\n", resp.Status)
+	if include {
+		printHeaders(cmd.OutOrStdout(), resp.Header)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to read response: %v", err)
+	}
+
+	printBody(cmd.OutOrStdout(), data)
+	return nil
+}
+
+// requestHeaders parses the extra headers to send.
+func requestHeaders(cmd *cobra.Command) (map[string]string, error) {
+	headers := map[string]string{}
+
+	values, _ := cmd.Flags().GetStringArray("header")
+	for _, header := range values {
+		name, value, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, utils.Error("invalid header '%s', must be 'Name: value'", header)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return headers, nil
+}
+
+// requestBody reads the JSON body from the command line, a file, or stdin.
+// An empty object is sent if no body was given.
+func requestBody(cmd *cobra.Command) (json.RawMessage, error) {
+	data, _ := cmd.Flags().GetString("data")
+
+	var body []byte
+	var err error
+	switch {
+	case data == "-" || data == "@-":
+		body, err = io.ReadAll(cmd.InOrStdin())
+	case strings.HasPrefix(data, "@"):
+		body, err = os.ReadFile(data[1:])
+	case data == "":
+		body = []byte("{}")
+	default:
+		body = []byte(data)
+	}
+
+	if err != nil {
+		return nil, utils.Error("unable to read request body: %v", err)
+	}
+
+	if !json.Valid(body) {
+		return nil, utils.Error("request body is not valid JSON")
+	}
+
+	return body, nil
+}
+
+// currentETag gets the ETag of a resource from its headers, or from the
+// @odata.etag property if the service does not send the header.
+func currentETag(c common.Client, uri string) (string, error) {
+	resp, err := c.Get(uri)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag, nil
+	}
+
+	var resource struct {
+		ETag string `json:"@odata.etag"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&resource)
+	if err != nil {
+		return "", err
+	}
+
+	if resource.ETag == "" {
+		return "", utils.Error("the resource does not have an ETag")
+	}
+
+	return resource.ETag, nil
+}
+
+// statusWriter gets where the response status is written. It is part of the
+// output when headers are included, otherwise it is kept out of the way of the
+// response body.
+func statusWriter(cmd *cobra.Command, include bool) io.Writer {
+	if include {
+		return cmd.OutOrStdout()
+	}
+
+	return cmd.ErrOrStderr()
+}
+
+// printHeaders writes the response headers in a stable order.
+func printHeaders(out io.Writer, headers http.Header) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fmt.Fprintf(out, "%s: %s\n", name, value)
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+// printBody writes the response body, indenting it if it is JSON.
+func printBody(out io.Writer, data []byte) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, bytes.TrimSpace(data), "", "  ") == nil {
+		fmt.Fprintln(out, pretty.String())
+		return
+	}
+
+	_, _ = out.Write(data)
+}
+
+// errorBody rebuilds the response body of a failed request from the error.
+func errorBody(rfErr *common.Error) []byte {
+	// The error text is the status code followed by the raw response
+	_, body, found := strings.Cut(rfErr.Error(), ": ")
+	if !found {
+		return nil
+	}
+
+	return []byte(body)
+}
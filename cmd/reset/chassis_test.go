@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package reset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestResetChassis(t *testing.T) {
+	server := mockuptest.Start(t)
+	err := server.Update("/redfish/v1/Chassis/1", map[string]interface{}{"PowerState": "Off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = mockuptest.Run(t, Cmd(), "chassis", "Chassis1")
+	if err != nil {
+		t.Fatalf("reset chassis failed: %v", err)
+	}
+
+	chassis, _ := server.Resource("/redfish/v1/Chassis/1")
+	if chassis["PowerState"] != "On" {
+		t.Errorf("expected the chassis to be on, got %v", chassis["PowerState"])
+	}
+}
+
+func TestResetChassisNotFound(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "chassis", "Chassis9")
+	if err == nil || !strings.Contains(err.Error(), "Chassis9") {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
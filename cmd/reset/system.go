@@ -3,7 +3,6 @@ package reset
 
 import (
 	"github.com/spf13/cobra"
-	"github.com/stmcginnis/gofish/common"
 	"github.com/stmcginnis/gofish/redfish"
 
 	"github.com/stmcginnis/ctlfish/utils"
@@ -22,7 +21,7 @@ func resetSystem(cmd *cobra.Command, args []string) error {
 	connection, _ := cmd.Flags().GetString("connection")
 	c, err := utils.GofishClient(connection)
 	if err != nil {
-		return utils.ErrorExit(cmd, err.Error())
+		return utils.ErrorExit(cmd, "%v", err)
 	}
 	defer c.Logout()
 
@@ -47,11 +46,7 @@ func resetSystem(cmd *cobra.Command, args []string) error {
 	// support letting the user specify, but for now just default to PowerCycle.
 	err = sys.Reset(redfish.PowerCycleResetType)
 	if err != nil {
-		msg := err.Error()
-		if rfErr, ok := err.(*common.Error); ok {
-			msg = rfErr.Message
-		}
-		return utils.ErrorExit(cmd, "error performing reset: %v", msg)
+		return utils.ErrorExit(cmd, "error performing reset: %v", err)
 	}
 	return nil
 }
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package reset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestResetSystem(t *testing.T) {
+	server := mockuptest.Start(t)
+	err := server.Update("/redfish/v1/Systems/1", map[string]interface{}{"PowerState": "Off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = mockuptest.Run(t, Cmd(), "system", "web01")
+	if err != nil {
+		t.Fatalf("reset system failed: %v", err)
+	}
+
+	// A power cycle turns the system back on
+	system, _ := server.Resource("/redfish/v1/Systems/1")
+	if system["PowerState"] != "On" {
+		t.Errorf("expected the system to be on, got %v", system["PowerState"])
+	}
+}
+
+func TestResetSystemNotFound(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "system", "db01")
+	if err == nil || !strings.Contains(err.Error(), "unable to locate system 'db01'") {
+		t.Errorf("expected not found error, got: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package restore
+
+import (
+	"strings"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/cmd/apply"
+	"github.com/stmcginnis/ctlfish/settings"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Cmd gets the restore command.
+func Cmd() *cobra.Command {
+	restoreCmd := &cobra.Command{
+		Use:   "restore FILE [CONNECTION]",
+		Short: "Restore settings from a backup file.",
+		Long: dedent.Dedent(`Restore the settings in a backup file, written by the backup command, to
+		the default connection or the given connection. This may be the node
+		the backup was taken from, or one that replaces it.
+
+		The settings of the node are compared with the backup, and the changes
+		needed to restore it are shown before anything is changed. Settings
+		that already match are left alone.
+
+		Backups do not hold passwords, so users that do not exist on the node
+		can only be created if their passwords are given with --user-password.
+		Other users are skipped.
+
+		BIOS changes take effect once the system is reset, which is done after
+		the changes are made with --reset. Read-only BIOS attributes, such as
+		the service tag, are not restored.
+
+		Settings the node cannot take, such as the power limit of a chassis it
+		does not have, or LDAP settings when it has no LDAP service, are
+		skipped with a warning, and the rest are still restored.`),
+		RunE: restoreSettings,
+		Args: cobra.RangeArgs(1, 2),
+	}
+
+	restoreCmd.Flags().StringArray("user-password", []string{},
+		"Password as `USER=PASSWORD` for creating a user that does not exist. May be repeated.")
+	apply.AddFlags(restoreCmd)
+	restoreCmd.Flags().SortFlags = true
+
+	return restoreCmd
+}
+
+// restoreSettings applies the settings of a backup.
+func restoreSettings(cmd *cobra.Command, args []string) error {
+	backup, err := settings.LoadBackup(args[0])
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	connection := ""
+	if len(args) > 1 {
+		connection = args[1]
+	}
+
+	passwords := map[string]string{}
+	values, _ := cmd.Flags().GetStringArray("user-password")
+	for _, value := range values {
+		user, password, found := strings.Cut(value, "=")
+		if !found || user == "" || password == "" {
+			return utils.ErrorExit(cmd, "invalid --user-password, must be 'USER=PASSWORD'")
+		}
+		passwords[user] = password
+	}
+
+	cmd.Printf("Restoring the settings of %s from %s.\n", backup.Source, backup.Created.Local().Format("2006-01-02 15:04:05"))
+
+	desired, err := withPasswords(cmd, connection, backup.Settings, passwords)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+
+	return apply.AvailableSettings(cmd, []string{connection}, desired)
+}
+
+// withPasswords adds the given passwords to the users in the settings that
+// do not exist on the connection, so they can be created. Users without a
+// password are left out, as they cannot be created.
+func withPasswords(cmd *cobra.Command, connection string, desired settings.Settings, passwords map[string]string) (settings.Settings, error) {
+	users, ok := desired[settings.Users].(map[string]interface{})
+	if !ok {
+		return desired, nil
+	}
+
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout()
+
+	current, err := settings.Read(c, settings.Users)
+	if err != nil {
+		return nil, err
+	}
+	existing, _ := current[settings.Users].(map[string]interface{})
+
+	result := settings.Settings{}
+	for name, value := range desired {
+		result[name] = value
+	}
+
+	restored := map[string]interface{}{}
+	for _, name := range utils.SortedKeys(users) {
+		properties, _ := users[name].(map[string]interface{})
+		_, exists := existing[name]
+		password, hasPassword := passwords[name]
+
+		switch {
+		case exists || properties == nil:
+			restored[name] = users[name]
+		case hasPassword:
+			withPassword := map[string]interface{}{"Password": password}
+			for key, value := range properties {
+				withPassword[key] = value
+			}
+			restored[name] = withPassword
+		default:
+			cmd.PrintErrf("Skipping user '%s', which does not exist. Give its password with --user-password to create it.\n", name)
+		}
+	}
+	result[settings.Users] = restored
+
+	return result, nil
+}
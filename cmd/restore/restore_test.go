@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package restore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+const backup = `
+version: 1
+source: web01
+created: 2026-10-19T08:30:00Z
+settings:
+  ldap:
+    ServiceEnabled: true
+  power_limit:
+    "1": {LimitInWatts: 500, LimitException: LogEventOnly}
+  users:
+    admin: {RoleId: Administrator, Enabled: true}
+    deploy: {RoleId: Operator, Enabled: true}
+`
+
+func TestRestore(t *testing.T) {
+	server := mockuptest.Start(t)
+	err := server.Update("/redfish/v1/AccountService", map[string]interface{}{
+		"LDAP": map[string]interface{}{"ServiceEnabled": false},
+	})
+	if err != nil {
+		t.Fatalf("unable to update mockup: %v", err)
+	}
+	path := mockuptest.WriteFile(t, "backup.yaml", backup)
+
+	// The deploy user is skipped without a password
+	output, err := mockuptest.Run(t, Cmd(), path, "--dry-run")
+	if err != nil {
+		t.Fatalf("restore failed: %v\n%s", err, output)
+	}
+	for _, expected := range []string{"Restoring the settings of web01", "Skipping user 'deploy'", "ldap.ServiceEnabled", "1 changes planned."} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+
+	output, err = mockuptest.Run(t, Cmd(), path, "mockup", "--user-password", "deploy=secret123", "--yes")
+	if err != nil || !strings.Contains(output, "Applied 2 changes.") {
+		t.Fatalf("restore failed: %v\n%s", err, output)
+	}
+
+	accounts, _ := server.Resource("/redfish/v1/AccountService")
+	if accounts["LDAP"].(map[string]interface{})["ServiceEnabled"] != true {
+		t.Errorf("expected LDAP to be enabled, got %v", accounts["LDAP"])
+	}
+	if account, _ := server.Resource("/redfish/v1/AccountService/Accounts/3"); account["UserName"] != "deploy" {
+		t.Errorf("expected the deploy user to be created, got %v", account)
+	}
+
+	output, err = mockuptest.Run(t, Cmd(), path, "--yes")
+	if err != nil || !strings.Contains(output, "Nothing to change") {
+		t.Errorf("expected nothing to change, got %v:\n%s", err, output)
+	}
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), mockuptest.WriteFile(t, "backup.yaml", "version: 2\nsettings: {}\n"))
+	if err == nil || !strings.Contains(err.Error(), "needs a newer version of ctlfish") {
+		t.Errorf("expected version error, got: %v", err)
+	}
+
+	_, err = mockuptest.Run(t, Cmd(), mockuptest.WriteFile(t, "backup.yaml", backup), "--user-password", "deploy")
+	if err == nil || !strings.Contains(err.Error(), "must be 'USER=PASSWORD'") {
+		t.Errorf("expected password error, got: %v", err)
+	}
+}
+
+func TestRestoreBIOS(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	// The service tag is read-only, and differs as the backup is of another node
+	path := mockuptest.WriteFile(t, "backup.yaml", `
+version: 1
+source: web02
+created: 2026-10-19T08:30:00Z
+settings:
+  bios:
+    BootMode: Bios
+    SystemServiceTag: 9KD4PQ1
+`)
+
+	output, err := mockuptest.Run(t, Cmd(), path, "--yes")
+	if err != nil || !strings.Contains(output, "Applied 1 changes.") {
+		t.Fatalf("restore failed: %v\n%s", err, output)
+	}
+	if strings.Contains(output, "SystemServiceTag") {
+		t.Errorf("the read-only service tag should not be restored:\n%s", output)
+	}
+
+	pending, _ := server.Resource("/redfish/v1/Systems/1/Bios/Settings")
+	attributes, _ := pending["Attributes"].(map[string]interface{})
+	if attributes["BootMode"] != "Bios" || attributes["SystemServiceTag"] != nil {
+		t.Errorf("unexpected pending attributes: %v", attributes)
+	}
+}
+
+func TestRestoreReplacement(t *testing.T) {
+	server := mockuptest.Start(t)
+	err := server.Update("/redfish/v1/AccountService", map[string]interface{}{"LDAP": nil})
+	if err != nil {
+		t.Fatalf("unable to update mockup: %v", err)
+	}
+
+	// The node the backup is of has a chassis with another ID, and an LDAP
+	// service, which the replacement does not have
+	path := mockuptest.WriteFile(t, "backup.yaml", `
+version: 1
+source: web02
+created: 2026-10-19T08:30:00Z
+settings:
+  indicator_led: Lit
+  ldap:
+    ServiceEnabled: true
+  power_limit:
+    System.Embedded.1: {LimitInWatts: 500, LimitException: LogEventOnly}
+`)
+
+	output, err := mockuptest.Run(t, Cmd(), path, "--yes")
+	if err != nil || !strings.Contains(output, "Applied 1 changes.") {
+		t.Fatalf("restore failed: %v\n%s", err, output)
+	}
+	for _, expected := range []string{
+		"Skipping ldap on the default connection: the service does not support these settings",
+		"Skipping power_limit.System.Embedded.1 on the default connection: there is no chassis 'System.Embedded.1'",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output is missing %q:\n%s", expected, output)
+		}
+	}
+
+	if system, _ := server.Resource("/redfish/v1/Systems/1"); system["IndicatorLED"] != "Lit" {
+		t.Errorf("expected the indicator LED to be restored, got %v", system["IndicatorLED"])
+	}
+}
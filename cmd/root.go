@@ -6,10 +6,26 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/stmcginnis/ctlfish/cmd/apply"
+	"github.com/stmcginnis/ctlfish/cmd/backup"
+	clearcmd "github.com/stmcginnis/ctlfish/cmd/clear"
+	"github.com/stmcginnis/ctlfish/cmd/collect"
+	"github.com/stmcginnis/ctlfish/cmd/create"
+	deletecmd "github.com/stmcginnis/ctlfish/cmd/delete"
+	"github.com/stmcginnis/ctlfish/cmd/diff"
+	"github.com/stmcginnis/ctlfish/cmd/events"
+	"github.com/stmcginnis/ctlfish/cmd/exporter"
 	"github.com/stmcginnis/ctlfish/cmd/get"
+	"github.com/stmcginnis/ctlfish/cmd/health"
+	"github.com/stmcginnis/ctlfish/cmd/inventory"
+	"github.com/stmcginnis/ctlfish/cmd/mockup"
+	"github.com/stmcginnis/ctlfish/cmd/raw"
 	"github.com/stmcginnis/ctlfish/cmd/reset"
+	"github.com/stmcginnis/ctlfish/cmd/restore"
 	"github.com/stmcginnis/ctlfish/cmd/set"
+	"github.com/stmcginnis/ctlfish/cmd/test"
 	"github.com/stmcginnis/ctlfish/config"
+	"github.com/stmcginnis/ctlfish/utils"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,9 +38,9 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	cmd, err := rootCmd.ExecuteC()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(utils.ExitCode(cmd, err))
 	}
 }
 
@@ -33,7 +49,48 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ctlfish.yaml)")
 	config.InitConfig(cfgFile)
 
+	rootCmd.PersistentFlags().StringVar(&utils.Options.RecordFile, "record", "",
+		"Save all requests and responses to `FILE`, with credentials removed.")
+	rootCmd.PersistentFlags().StringVar(&utils.Options.ReplayFile, "replay", "",
+		"Answer requests from the recording in `FILE` instead of contacting the service.")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().CountVarP(&utils.Options.DebugLevel, "debug", "v",
+		"Log each request to stderr. Repeat (-vv) to include headers and bodies.")
+	rootCmd.PersistentFlags().StringVar(&utils.Options.LogFile, "log-file", "",
+		"Write the --debug log to `FILE` instead of stderr.")
+
+	rootCmd.PersistentFlags().DurationVar(&utils.Options.Timeout, "timeout", 0,
+		"Time allowed for each request, overriding the connection setting (default 2m).")
+	rootCmd.PersistentFlags().DurationVar(&utils.Options.ConnectTimeout, "connect-timeout", 0,
+		"Time allowed to connect, overriding the connection setting (default 30s).")
+	retries := 0
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 0,
+		"Times to retry failed requests, overriding the connection setting (default 2).")
+	rootCmd.PersistentFlags().Float64Var(&utils.Options.RateLimit, "rate-limit", 0,
+		"Most requests to send per second, overriding the connection setting.")
+	cobra.OnInitialize(func() {
+		// Zero is a valid value, so the setting only applies if given
+		if rootCmd.PersistentFlags().Changed("retries") {
+			utils.Options.Retries = &retries
+		}
+	})
+
+	rootCmd.AddCommand(apply.Cmd())
+	rootCmd.AddCommand(backup.Cmd())
+	rootCmd.AddCommand(clearcmd.Cmd())
+	rootCmd.AddCommand(collect.Cmd())
+	rootCmd.AddCommand(create.Cmd())
+	rootCmd.AddCommand(deletecmd.Cmd())
+	rootCmd.AddCommand(diff.Cmd())
+	rootCmd.AddCommand(events.Cmd())
+	rootCmd.AddCommand(exporter.Cmd())
 	rootCmd.AddCommand(get.Cmd())
+	rootCmd.AddCommand(health.Cmd())
+	rootCmd.AddCommand(inventory.Cmd())
+	rootCmd.AddCommand(mockup.Cmd())
+	rootCmd.AddCommand(raw.Cmd())
 	rootCmd.AddCommand(reset.Cmd())
+	rootCmd.AddCommand(restore.Cmd())
 	rootCmd.AddCommand(set.Cmd())
+	rootCmd.AddCommand(test.Cmd())
 }
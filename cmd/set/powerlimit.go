@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package set
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/redfish"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var powerLimitCmd = &cobra.Command{
+	Use:     "powerlimit",
+	Aliases: []string{"powercap", "pl"},
+	Short:   "Set chassis power limits.",
+	Long: "Sets or disables the power limit (power cap) of one or more chassis. " +
+		"The limit is validated against the power capacity reported by the chassis.",
+	RunE: updatePowerLimit,
+	Args: cobra.NoArgs,
+}
+
+var powerLimitExceptions = []string{
+	string(redfish.LogEventOnlyPowerLimitException),
+	string(redfish.HardPowerOffPowerLimitException),
+	string(redfish.NoActionPowerLimitException),
+	string(redfish.OemPowerLimitException),
+}
+
+func init() {
+	powerLimitCmd.Flags().StringSlice("chassis", []string{}, "Chassis name or ID to limit. May be repeated or comma separated.")
+	powerLimitCmd.Flags().Int("watts", 0, "The power limit in watts.")
+	powerLimitCmd.Flags().String("exception", "",
+		fmt.Sprintf("Action to take when the limit is exceeded (%s).", strings.Join(powerLimitExceptions, ", ")))
+	powerLimitCmd.Flags().Int64("correction-ms", 0, "Time in milliseconds allowed to bring consumption under the limit.")
+	powerLimitCmd.Flags().Bool("disable", false, "Remove the power limit.")
+	powerLimitCmd.Flags().SortFlags = true
+
+	_ = powerLimitCmd.MarkFlagRequired("chassis")
+	powerLimitCmd.MarkFlagsOneRequired("watts", "disable")
+	powerLimitCmd.MarkFlagsMutuallyExclusive("watts", "disable")
+	powerLimitCmd.MarkFlagsMutuallyExclusive("exception", "disable")
+	powerLimitCmd.MarkFlagsMutuallyExclusive("correction-ms", "disable")
+}
+
+// powerLimitSettings are the requested power limit changes.
+type powerLimitSettings struct {
+	watts        int
+	exception    string
+	correctionMs int64
+	disable      bool
+}
+
+// updatePowerLimit applies the power limit to each requested chassis.
+func updatePowerLimit(cmd *cobra.Command, _ []string) error {
+	names, _ := cmd.Flags().GetStringSlice("chassis")
+	settings := powerLimitSettings{}
+	settings.watts, _ = cmd.Flags().GetInt("watts")
+	settings.exception, _ = cmd.Flags().GetString("exception")
+	settings.correctionMs, _ = cmd.Flags().GetInt64("correction-ms")
+	settings.disable, _ = cmd.Flags().GetBool("disable")
+
+	if !settings.disable && settings.watts <= 0 {
+		return utils.ErrorExit(cmd, "power limit must be a positive number of watts")
+	}
+
+	if settings.exception != "" {
+		found := false
+		for _, exception := range powerLimitExceptions {
+			if strings.EqualFold(settings.exception, exception) {
+				settings.exception = exception
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return utils.ErrorExit(
+				cmd, "invalid exception '%s', must be one of: %s",
+				settings.exception, strings.Join(powerLimitExceptions, ", "))
+		}
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	chassis, err := c.Service.Chassis()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to retrieve chassis information: %v", err)
+	}
+
+	// Make sure everything requested exists before changing anything
+	targets := []*redfish.Chassis{}
+	for _, name := range names {
+		idx := slices.IndexFunc(chassis, func(chass *redfish.Chassis) bool {
+			return chass.ID == name || chass.Name == name
+		})
+		if idx == -1 {
+			return utils.ErrorExit(cmd, "unable to locate chassis '%s'", name)
+		}
+		targets = append(targets, chassis[idx])
+	}
+
+	failures := 0
+	writer := utils.NewTableWriter(cmd.OutOrStdout(), "chassis", "limit", "result")
+	for _, chass := range targets {
+		limit := fmt.Sprintf("%d W", settings.watts)
+		if settings.disable {
+			limit = "disabled"
+		}
+
+		result := "OK"
+		err := setChassisPowerLimit(chass, &settings)
+		if err != nil {
+			result = utils.ErrorMessage(err)
+			failures++
+		}
+
+		writer.AddRow(chass.Name, limit, result)
+	}
+	writer.Render()
+
+	if failures > 0 {
+		return utils.ErrorExit(cmd, "failed to set power limit on %d of %d chassis", failures, len(targets))
+	}
+	return nil
+}
+
+// setChassisPowerLimit sets the limit using the deprecated Power resource if the
+// chassis has one, otherwise through its EnvironmentMetrics.
+func setChassisPowerLimit(chass *redfish.Chassis, settings *powerLimitSettings) error {
+	power, err := chass.Power()
+	if err != nil {
+		return err
+	}
+
+	if power != nil && len(power.PowerControl) > 0 {
+		return setLegacyPowerLimit(power, settings)
+	}
+
+	metrics, err := chass.EnvironmentMetrics()
+	if err != nil {
+		return err
+	}
+
+	if metrics == nil {
+		return utils.Error("chassis does not support power limits")
+	}
+
+	subsystem, err := chass.PowerSubsystem()
+	if err != nil {
+		return err
+	}
+
+	capacity := 0.0
+	if subsystem != nil {
+		capacity = subsystem.CapacityWatts
+	}
+
+	return setEnvironmentPowerLimit(metrics, capacity, settings)
+}
+
+// setLegacyPowerLimit updates the PowerLimit of the chassis PowerControl.
+func setLegacyPowerLimit(power *redfish.Power, settings *powerLimitSettings) error {
+	control := &power.PowerControl[0]
+
+	limit := map[string]interface{}{}
+	if settings.disable {
+		// A null limit removes it
+		limit["LimitInWatts"] = nil
+	} else {
+		capacity := control.PowerCapacityWatts
+		if capacity > 0 && float32(settings.watts) > capacity {
+			return utils.Error("limit of %d W exceeds the power capacity of %g W", settings.watts, capacity)
+		}
+
+		limit["LimitInWatts"] = settings.watts
+		if settings.exception != "" {
+			limit["LimitException"] = settings.exception
+		}
+		if settings.correctionMs > 0 {
+			limit["CorrectionInMs"] = settings.correctionMs
+		}
+	}
+
+	// Array members that are not changing must be sent as empty objects
+	controls := []interface{}{map[string]interface{}{"PowerLimit": limit}}
+	for i := 1; i < len(power.PowerControl); i++ {
+		controls = append(controls, map[string]interface{}{})
+	}
+
+	return power.Patch(power.ODataID, map[string]interface{}{"PowerControl": controls})
+}
+
+// setEnvironmentPowerLimit updates the PowerLimitWatts control of the chassis
+// EnvironmentMetrics, checking it against the capacity of the PowerSubsystem if
+// known. This model has no exception or correction time settings.
+func setEnvironmentPowerLimit(metrics *redfish.EnvironmentMetrics, capacity float64, settings *powerLimitSettings) error {
+	if settings.exception != "" || settings.correctionMs > 0 {
+		return utils.Error("exception and correction time are not supported by this chassis")
+	}
+
+	control := metrics.PowerLimitWatts
+	limit := map[string]interface{}{}
+	if settings.disable {
+		limit["ControlMode"] = redfish.DisabledControlMode
+	} else {
+		watts := float64(settings.watts)
+		if capacity > 0 && watts > capacity {
+			return utils.Error("limit of %d W exceeds the power capacity of %g W", settings.watts, capacity)
+		}
+		if control.AllowableMax > 0 && watts > control.AllowableMax {
+			return utils.Error("limit of %d W exceeds the maximum allowed limit of %g W", settings.watts, control.AllowableMax)
+		}
+		if watts < control.AllowableMin {
+			return utils.Error("limit of %d W is below the minimum allowed limit of %g W", settings.watts, control.AllowableMin)
+		}
+
+		limit["SetPoint"] = settings.watts
+		limit["ControlMode"] = redfish.AutomaticControlMode
+	}
+
+	return metrics.Patch(metrics.ODataID, map[string]interface{}{"PowerLimitWatts": limit})
+}
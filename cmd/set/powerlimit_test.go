@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup"
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+// powerLimit gets the PowerLimit of the first PowerControl of the test chassis.
+func powerLimit(t *testing.T, server *mockup.Server) map[string]interface{} {
+	t.Helper()
+
+	power, found := server.Resource("/redfish/v1/Chassis/1/Power")
+	if !found {
+		t.Fatal("power resource was not found")
+	}
+
+	control := power["PowerControl"].([]interface{})[0].(map[string]interface{})
+	return control["PowerLimit"].(map[string]interface{})
+}
+
+func TestSetPowerLimit(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "powerlimit", "--chassis", "1", "--watts", "600", "--exception", "hardpoweroff")
+	if err != nil {
+		t.Fatalf("set powerlimit failed: %v\n%s", err, output)
+	}
+
+	limit := powerLimit(t, server)
+	if limit["LimitInWatts"] != float64(600) || limit["LimitException"] != "HardPowerOff" {
+		t.Errorf("unexpected power limit: %v", limit)
+	}
+}
+
+func TestSetPowerLimitDisable(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	_, err := mockuptest.Run(t, Cmd(), "powerlimit", "--chassis", "Chassis1", "--disable")
+	if err != nil {
+		t.Fatalf("set powerlimit failed: %v", err)
+	}
+
+	if limit := powerLimit(t, server); limit["LimitInWatts"] != nil {
+		t.Errorf("expected the limit to be removed, got: %v", limit)
+	}
+}
+
+func TestSetPowerLimitOverCapacity(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "powerlimit", "--chassis", "1", "--watts", "900")
+	if err == nil {
+		t.Fatal("expected the limit to be rejected")
+	}
+
+	if !strings.Contains(output, "exceeds the power capacity of 800 W") {
+		t.Errorf("output is missing the reason:\n%s", output)
+	}
+
+	if limit := powerLimit(t, server); limit["LimitInWatts"] != float64(500) {
+		t.Errorf("power limit was changed: %v", limit)
+	}
+}
+
+// useEnvironmentMetrics removes the Power resource of the test chassis, so
+// power limits are set through its EnvironmentMetrics.
+func useEnvironmentMetrics(t *testing.T, server *mockup.Server) {
+	t.Helper()
+
+	err := server.Update("/redfish/v1/Chassis/1", map[string]interface{}{"Power": nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// powerLimitWatts gets the PowerLimitWatts control of the test chassis.
+func powerLimitWatts(t *testing.T, server *mockup.Server) map[string]interface{} {
+	t.Helper()
+
+	metrics, found := server.Resource("/redfish/v1/Chassis/1/EnvironmentMetrics")
+	if !found {
+		t.Fatal("environment metrics resource was not found")
+	}
+
+	return metrics["PowerLimitWatts"].(map[string]interface{})
+}
+
+func TestSetPowerLimitEnvironmentMetrics(t *testing.T) {
+	server := mockuptest.Start(t)
+	useEnvironmentMetrics(t, server)
+
+	output, err := mockuptest.Run(t, Cmd(), "powerlimit", "--chassis", "1", "--watts", "600")
+	if err != nil {
+		t.Fatalf("set powerlimit failed: %v\n%s", err, output)
+	}
+
+	if limit := powerLimitWatts(t, server); limit["SetPoint"] != float64(600) {
+		t.Errorf("unexpected power limit: %v", limit)
+	}
+}
+
+func TestSetPowerLimitEnvironmentMetricsOverCapacity(t *testing.T) {
+	server := mockuptest.Start(t)
+	useEnvironmentMetrics(t, server)
+
+	// The allowable maximum of the control is above the capacity of the chassis
+	output, err := mockuptest.Run(t, Cmd(), "powerlimit", "--chassis", "1", "--watts", "900")
+	if err == nil {
+		t.Fatal("expected the limit to be rejected")
+	}
+
+	if !strings.Contains(output, "exceeds the power capacity of 800 W") {
+		t.Errorf("output is missing the reason:\n%s", output)
+	}
+
+	if limit := powerLimitWatts(t, server); limit["SetPoint"] != float64(500) {
+		t.Errorf("power limit was changed: %v", limit)
+	}
+}
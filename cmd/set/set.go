@@ -12,6 +12,7 @@ func Cmd() *cobra.Command {
 		Short:   "Set or update object attributes.",
 	}
 
+	setCmd.AddCommand(powerLimitCmd)
 	setCmd.AddCommand(userCmd)
 
 	return setCmd
@@ -31,7 +31,7 @@ func updateUser(cmd *cobra.Command, args []string) error {
 	connection, _ := cmd.Flags().GetString("connection")
 	c, err := utils.GofishClient(connection)
 	if err != nil {
-		return utils.ErrorExit(cmd, err.Error())
+		return utils.ErrorExit(cmd, "%v", err)
 	}
 	defer c.Logout()
 
@@ -56,6 +56,10 @@ func updateUser(cmd *cobra.Command, args []string) error {
 		break
 	}
 
+	if user == nil {
+		return utils.ErrorExit(cmd, "user '%s' was not found.", args[0])
+	}
+
 	usernameFlag := cmd.Flag("username")
 	if usernameFlag.Changed {
 		// TODO: since we retrieved all accounts, might be good to add validation
@@ -83,7 +87,7 @@ func updateUser(cmd *cobra.Command, args []string) error {
 		// Validate the role being set
 		roles, err := as.Roles()
 		if err != nil {
-			return utils.ErrorExit(cmd, "unable to retrieve available roles: %s", err.Error())
+			return utils.ErrorExit(cmd, "unable to retrieve available roles: %v", err)
 		}
 
 		newRole := strings.ToLower(roleFlag.Value.String())
@@ -103,7 +107,7 @@ func updateUser(cmd *cobra.Command, args []string) error {
 
 	err = user.Update()
 	if err != nil {
-		return utils.ErrorExit(cmd, "error updating user '%s': %s", user.UserName, err.Error())
+		return utils.ErrorExit(cmd, "error updating user '%s': %v", user.UserName, err)
 	}
 
 	writer := utils.NewTableWriter(cmd.OutOrStdout(), "name", "role", "enabled", "description")
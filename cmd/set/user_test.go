@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package set
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+)
+
+func TestSetUserRole(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	output, err := mockuptest.Run(t, Cmd(), "user", "operator", "--role", "readonly")
+	if err != nil {
+		t.Fatalf("set user failed: %v", err)
+	}
+
+	if !strings.Contains(output, "ReadOnly") {
+		t.Errorf("output is missing the new role:\n%s", output)
+	}
+
+	account, _ := server.Resource("/redfish/v1/AccountService/Accounts/2")
+	if account["RoleId"] != "ReadOnly" {
+		t.Errorf("expected role ReadOnly, got %v", account["RoleId"])
+	}
+}
+
+func TestSetUserInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"unknown user", []string{"nobody", "--role", "Operator"}, "user 'nobody' was not found."},
+		{"unknown role", []string{"operator", "--role", "superuser"}, "role 'superuser' was not found"},
+		{"short password", []string{"operator", "--password", "short"}, "must be between 8 - 20 in length"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := mockuptest.Start(t)
+
+			_, err := mockuptest.Run(t, Cmd(), append([]string{"user"}, test.args...)...)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error containing %q, got: %v", test.expected, err)
+			}
+
+			account, _ := server.Resource("/redfish/v1/AccountService/Accounts/2")
+			if account["RoleId"] != "Operator" {
+				t.Errorf("account was changed: %v", account["RoleId"])
+			}
+		})
+	}
+}
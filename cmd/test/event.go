@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+var eventCmd = &cobra.Command{
+	Use:   "event",
+	Short: "Send a test event to the event subscriptions.",
+	Long: dedent.Dedent(`Send a test event to the event subscriptions using the SubmitTestEvent action.
+
+	If the service only supports the newer TestEventSubscription action, it is
+	used instead and the service chooses the event content.`),
+	RunE: testEvent,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	eventCmd.Flags().String("message-id", "ResourceEvent.1.0.ResourceChanged", "The message ID of the test event.")
+	eventCmd.Flags().String("message", "Test event sent by ctlfish.", "The message of the test event.")
+	eventCmd.Flags().StringSlice("args", []string{}, "The message arguments of the test event.")
+	eventCmd.Flags().String("severity", "OK", "The severity of the test event (OK, Warning, Critical).")
+	eventCmd.Flags().String("event-type", "Alert", "The event type for services that require one. Set to empty to omit.")
+	eventCmd.Flags().SortFlags = true
+}
+
+// testEvent asks the event service to send a test event.
+func testEvent(cmd *cobra.Command, _ []string) error {
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	eventService, err := c.Service.EventService()
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to access event service: %v", err)
+	}
+
+	if eventService.SubmitTestEventTarget == "" {
+		err = eventService.TestEventSubscription()
+		if err != nil {
+			return utils.ErrorExit(cmd, "failed to send test event: %v", err)
+		}
+
+		cmd.Println("Test event sent.")
+		return nil
+	}
+
+	// gofish's SubmitTestEvent does not allow setting the event details and
+	// sends a timestamp format some services reject, so post it directly
+	now := time.Now()
+	event := map[string]interface{}{
+		"EventId":           fmt.Sprintf("%d", now.Unix()),
+		"EventTimestamp":    now.Format(time.RFC3339),
+		"OriginOfCondition": eventService.ODataID,
+	}
+	event["MessageId"], _ = cmd.Flags().GetString("message-id")
+	event["Message"], _ = cmd.Flags().GetString("message")
+	event["Severity"], _ = cmd.Flags().GetString("severity")
+
+	args, _ := cmd.Flags().GetStringSlice("args")
+	if len(args) > 0 {
+		event["MessageArgs"] = args
+	}
+
+	eventType, _ := cmd.Flags().GetString("event-type")
+	if eventType != "" {
+		event["EventType"] = eventType
+	}
+
+	err = eventService.Post(eventService.SubmitTestEventTarget, event)
+	if err != nil {
+		return utils.ErrorExit(cmd, "failed to send test event: %v", err)
+	}
+
+	cmd.Println("Test event sent.")
+	return nil
+}
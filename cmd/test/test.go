@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package test
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func Cmd() *cobra.Command {
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Test service features.",
+	}
+
+	testCmd.AddCommand(eventCmd)
+
+	return testCmd
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// treeWorkers is the number of resources fetched at the same time.
+const treeWorkers = 8
+
+// treeCmd prints the resource hierarchy of a service.
+var treeCmd = &cobra.Command{
+	Use:   "tree [URI]",
+	Short: "Print the resource hierarchy.",
+	Long: dedent.Dedent(`Print the resource hierarchy, starting at the service root or the given URI.
+
+	The tree is built by following the @odata.id links of each resource. Only
+	links to resources contained below a resource are followed, references to
+	resources elsewhere in the tree are left out.`),
+	RunE: printTree,
+	Args: cobra.MaximumNArgs(1),
+}
+
+func init() {
+	treeCmd.Flags().Int("depth", 3, "Number of levels to show below the starting resource, 0 for no limit.")
+	treeCmd.Flags().Bool("uris", false, "Show the full URI of each resource.")
+	treeCmd.Flags().SortFlags = true
+
+	rootCmd.AddCommand(treeCmd)
+}
+
+// treeNode is a resource in the printed tree.
+type treeNode struct {
+	uri      string
+	links    []utils.ResourceLink
+	err      error
+	children []*treeNode
+}
+
+// printTree crawls the resources and prints them as a tree.
+func printTree(cmd *cobra.Command, args []string) error {
+	uri := utils.ServiceRoot
+	if len(args) == 1 {
+		uri = strings.TrimSuffix(utils.ResourceURI(args[0]), "/")
+	}
+
+	depth, _ := cmd.Flags().GetInt("depth")
+	if depth < 0 {
+		return utils.ErrorExit(cmd, "depth must not be negative")
+	}
+
+	connection, _ := cmd.Flags().GetString("connection")
+	c, err := utils.GofishClient(connection)
+	if err != nil {
+		return utils.ErrorExit(cmd, "%v", err)
+	}
+	defer c.Logout()
+
+	root := crawlTree(c, uri, depth)
+	if root.err != nil {
+		return utils.ErrorExit(cmd, "failed to get '%s': %v", uri, root.err)
+	}
+
+	uris, _ := cmd.Flags().GetBool("uris")
+	fmt.Fprintln(cmd.OutOrStdout(), root.uri)
+	writeTree(cmd.OutOrStdout(), root, "", uris)
+
+	return nil
+}
+
+// crawlTree gets the resources one level at a time, so the tree does not
+// depend on the order the responses arrive in.
+func crawlTree(c common.Client, uri string, depth int) *treeNode {
+	root := &treeNode{uri: uri}
+	visited := map[string]bool{uri: true}
+
+	level := []*treeNode{root}
+	for current := 0; len(level) > 0 && (depth == 0 || current < depth); current++ {
+		fetchLinks(c, level)
+
+		next := []*treeNode{}
+		for _, node := range level {
+			for _, link := range node.links {
+				if visited[link.URI] || !link.Contained(node.uri) {
+					continue
+				}
+				visited[link.URI] = true
+
+				child := &treeNode{uri: link.URI}
+				node.children = append(node.children, child)
+				next = append(next, child)
+			}
+		}
+		level = next
+	}
+
+	return root
+}
+
+// fetchLinks gets the links of each of the nodes.
+func fetchLinks(c common.Client, nodes []*treeNode) {
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, treeWorkers)
+
+	for _, node := range nodes {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(node *treeNode) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			resource, err := utils.GetResource(c, node.uri)
+			if err != nil {
+				node.err = err
+				return
+			}
+			node.links = resource.Links()
+		}(node)
+	}
+
+	wg.Wait()
+}
+
+// writeTree prints the children of a node, with lines connecting them.
+func writeTree(out io.Writer, node *treeNode, prefix string, fullURIs bool) {
+	for i, child := range node.children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.children)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		name := strings.TrimPrefix(child.uri, node.uri+"/")
+		if fullURIs {
+			name = child.uri
+		}
+		if child.err != nil {
+			name += fmt.Sprintf(" (error: %s)", utils.ErrorMessage(child.err))
+		}
+
+		fmt.Fprintf(out, "%s%s%s\n", prefix, branch, name)
+		writeTree(out, child, prefix+indent, fullURIs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
@@ -20,16 +21,43 @@ type SystemConfig struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
 	Secure   bool   `yaml:"secure"`
+
+	// Timeout limits how long each request may take, including reading the
+	// response. A default is used if not set.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// ConnectTimeout limits how long connecting to the service may take.
+	ConnectTimeout time.Duration `yaml:"connect_timeout,omitempty" mapstructure:"connect_timeout"`
+	// Retries is how many times failed requests are retried. A default is
+	// used if not set.
+	Retries *int `yaml:"retries,omitempty"`
+	// RateLimit is the most requests per second sent to the service. There
+	// is no limit if not set.
+	RateLimit float64 `yaml:"rate_limit,omitempty" mapstructure:"rate_limit"`
+
+	// Proxy is the URL of an HTTP, HTTPS or SOCKS5 proxy to connect through.
+	Proxy string `yaml:"proxy,omitempty"`
+	// JumpHost is an SSH server to connect through, as host or host:port.
+	JumpHost string `yaml:"jump_host,omitempty" mapstructure:"jump_host"`
+	// JumpUser is the user to log in to the jump host as. The current user is
+	// used if not set.
+	JumpUser string `yaml:"jump_user,omitempty" mapstructure:"jump_user"`
+	// JumpKey is the private key file used to log in to the jump host. The SSH
+	// agent is used if not set.
+	JumpKey string `yaml:"jump_key,omitempty" mapstructure:"jump_key"`
 }
 
 // Config is the configuration settings we use.
 type Config struct {
 	Default string                  `yaml:"default"`
 	Systems map[string]SystemConfig `yaml:"systems"`
+	// Groups are named lists of systems that commands can run against together.
+	Groups map[string][]string `yaml:"groups,omitempty"`
 }
 
-// InitConfig reads in config file and ENV variables if set.
+// InitConfig reads in config file and ENV variables if set. Any config read
+// before is replaced.
 func InitConfig(cfgFile string) {
+	viper.Reset()
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
@@ -53,6 +81,7 @@ func InitConfig(cfgFile string) {
 
 	viper.SetDefault("default", "")
 	viper.SetDefault("systems", (&Config{}).Systems)
+	viper.SetDefault("groups", (&Config{}).Groups)
 
 	// Write out config file so it is created on first run
 	_ = viper.SafeWriteConfig()
@@ -67,6 +96,7 @@ func loadConfig() {
 		os.Exit(1)
 	}
 
+	appConfig = Config{}
 	err := viper.Unmarshal(&appConfig)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
@@ -142,14 +172,26 @@ func AddSystemConfig(name string, sysConfig *SystemConfig, makeDefault bool) err
 }
 
 // RemoveSystemConfig removes system config settings. If the system being removed
-// was the default connection, default is set to nothing.
+// was the default connection, default is set to nothing. The system is also
+// removed from any groups.
 func RemoveSystemConfig(name string) error {
 	if appConfig.Default == name {
 		appConfig.Default = ""
 	}
 
+	for group, systems := range appConfig.Groups {
+		members := []string{}
+		for _, system := range systems {
+			if system != name {
+				members = append(members, system)
+			}
+		}
+		appConfig.Groups[group] = members
+	}
+
 	delete(appConfig.Systems, name)
 	viper.Set("systems", appConfig.Systems)
+	viper.Set("groups", appConfig.Groups)
 	err := viper.WriteConfig()
 	return err
 }
@@ -169,6 +211,46 @@ func SetDefault(name string) error {
 	return fmt.Errorf("no system named %s", name)
 }
 
+// GetGroup gets the names of the systems in a group, or nil if there is no
+// group with the name.
+func GetGroup(name string) []string {
+	return appConfig.Groups[name]
+}
+
+// GetGroups gets all configured groups.
+func GetGroups() map[string][]string {
+	return appConfig.Groups
+}
+
+// SetGroup adds or updates a group of systems. All of the systems must exist.
+func SetGroup(name string, systems []string) error {
+	for _, system := range systems {
+		if _, ok := appConfig.Systems[system]; !ok {
+			return fmt.Errorf("no system named %s", system)
+		}
+	}
+
+	if appConfig.Groups == nil {
+		appConfig.Groups = map[string][]string{}
+	}
+	appConfig.Groups[name] = systems
+	viper.Set("groups", appConfig.Groups)
+	err := viper.WriteConfig()
+	return err
+}
+
+// RemoveGroup removes a group. The systems in it are not changed.
+func RemoveGroup(name string) error {
+	if _, ok := appConfig.Groups[name]; !ok {
+		return fmt.Errorf("no group named %s", name)
+	}
+
+	delete(appConfig.Groups, name)
+	viper.Set("groups", appConfig.Groups)
+	err := viper.WriteConfig()
+	return err
+}
+
 // GetDefault returns the name of the system to be used as the default connection.
 func GetDefault() string {
 	return appConfig.Default
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// resetPowerStates maps reset types to the power state they leave a resource
+// in. PushPowerButton toggles the power state instead.
+var resetPowerStates = map[string]string{
+	"On":               "On",
+	"ForceOn":          "On",
+	"ForceRestart":     "On",
+	"GracefulRestart":  "On",
+	"PowerCycle":       "On",
+	"Nmi":              "On",
+	"Resume":           "On",
+	"ForceOff":         "Off",
+	"GracefulShutdown": "Off",
+	"Suspend":          "Off",
+	"Pause":            "Off",
+}
+
+// post runs the action with the given target URI, or creates a member of a
+// collection.
+func (s *Server) post(w http.ResponseWriter, r *http.Request, uri string) {
+	resource, name, action := s.findAction(uri)
+	if action == nil {
+		if s.creatable(uri) {
+			s.create(w, r, uri)
+			return
+		}
+		if _, found := s.resources[uri]; found {
+			writeError(w, http.StatusMethodNotAllowed, "OperationNotAllowed")
+			return
+		}
+		writeError(w, http.StatusNotFound, "ResourceMissingAtURI", uri)
+		return
+	}
+
+	parameters := map[string]interface{}{}
+	if r.ContentLength != 0 && json.NewDecoder(r.Body).Decode(&parameters) != nil {
+		writeError(w, http.StatusBadRequest, "MalformedJSON")
+		return
+	}
+
+	// Only resets are implemented, the name is the part after the schema
+	if !strings.HasSuffix(name, ".Reset") {
+		writeError(w, http.StatusNotImplemented, "ActionNotSupported", strings.TrimPrefix(name, "#"))
+		return
+	}
+
+	resetType, _ := parameters["ResetType"].(string)
+	if resetType == "" {
+		writeError(w, http.StatusBadRequest, "ActionParameterMissing", strings.TrimPrefix(name, "#"), "ResetType")
+		return
+	}
+
+	valid := resetPowerStates[resetType] != "" || resetType == "PushPowerButton"
+	if allowed, ok := action["ResetType@Redfish.AllowableValues"].([]interface{}); ok {
+		valid = slices.Contains(allowed, interface{}(resetType))
+	}
+	if !valid {
+		writeError(w, http.StatusBadRequest, "ActionParameterValueNotInList", resetType, "ResetType", strings.TrimPrefix(name, "#"))
+		return
+	}
+
+	if state, ok := resource["PowerState"].(string); ok {
+		switch {
+		case resetType == "PushPowerButton" && state == "On":
+			resource["PowerState"] = "Off"
+		case resetType == "PushPowerButton":
+			resource["PowerState"] = "On"
+		case resetPowerStates[resetType] != "":
+			resource["PowerState"] = resetPowerStates[resetType]
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findAction finds the resource with an action targeting the URI, returning
+// the resource, the action name and the action.
+func (s *Server) findAction(uri string) (map[string]interface{}, string, map[string]interface{}) {
+	for _, resource := range s.resources {
+		actions, ok := resource["Actions"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for name, value := range actions {
+			action, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if target, ok := action["target"].(string); ok && normalize(target) == uri {
+				return resource, name, action
+			}
+		}
+	}
+
+	return nil, "", nil
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// memberType describes the resources clients can create in a collection.
+type memberType struct {
+	odataType string
+	// required are the properties that must be given when creating one.
+	required []string
+	// defaults are the properties the service fills in when not given.
+	defaults map[string]interface{}
+}
+
+// memberTypes are the collections that clients can create members in, by the
+// type of the collection.
+var memberTypes = map[string]memberType{
+	"ManagerAccountCollection": {
+		odataType: "#ManagerAccount.v1_12_0.ManagerAccount",
+		required:  []string{"UserName", "Password", "RoleId"},
+		defaults:  map[string]interface{}{"Enabled": true, "Locked": false},
+	},
+	"EventDestinationCollection": {
+		odataType: "#EventDestination.v1_13_0.EventDestination",
+		required:  []string{"Destination", "Protocol"},
+	},
+}
+
+// collectionType gets the type name of a collection, such as
+// ManagerAccountCollection.
+func (s *Server) collectionType(uri string) string {
+	collection, found := s.resources[uri]
+	if !found {
+		return ""
+	}
+
+	odataType, _ := collection["@odata.type"].(string)
+	name, _, _ := strings.Cut(strings.TrimPrefix(odataType, "#"), ".")
+	return name
+}
+
+// creatable checks if clients can create and delete members of a collection.
+func (s *Server) creatable(uri string) bool {
+	_, ok := memberTypes[s.collectionType(uri)]
+	return ok
+}
+
+// create adds a member to a collection from the request body.
+func (s *Server) create(w http.ResponseWriter, r *http.Request, collectionURI string) {
+	member := memberTypes[s.collectionType(collectionURI)]
+
+	properties := map[string]interface{}{}
+	if json.NewDecoder(r.Body).Decode(&properties) != nil {
+		writeError(w, http.StatusBadRequest, "MalformedJSON")
+		return
+	}
+
+	for _, property := range member.required {
+		if value, _ := properties[property].(string); value == "" {
+			writeError(w, http.StatusBadRequest, "CreateFailedMissingReqProperties", property)
+			return
+		}
+	}
+
+	id := 1
+	for s.resources[fmt.Sprintf("%s/%d", collectionURI, id)] != nil {
+		id++
+	}
+	uri := fmt.Sprintf("%s/%d", collectionURI, id)
+
+	resource := map[string]interface{}{}
+	for key, value := range member.defaults {
+		resource[key] = value
+	}
+	for key, value := range properties {
+		if key == "Password" {
+			// Passwords are accepted but never shown
+			value = nil
+		}
+		resource[key] = value
+	}
+	resource["@odata.id"] = uri
+	resource["@odata.type"] = member.odataType
+	resource["Id"] = strconv.Itoa(id)
+	if _, ok := resource["Name"]; !ok {
+		resource["Name"] = strings.TrimPrefix(strings.Split(member.odataType, ".")[0], "#")
+	}
+
+	s.resources[uri] = resource
+	s.addMember(collectionURI, uri)
+
+	w.Header().Set("Location", uri)
+	writeResource(w, http.StatusCreated, resource)
+}
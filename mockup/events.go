@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// eventStream is a client reading the server-sent event stream.
+type eventStream chan []byte
+
+// SendEvent sends an event to the clients reading the server-sent event
+// stream of the event service, returning how many it was sent to.
+func (s *Server) SendEvent(event map[string]interface{}) (int, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+
+	sent := 0
+	for stream := range s.streams {
+		select {
+		case stream <- data:
+			sent++
+		default:
+			// Clients that are not keeping up miss events, as they would
+			// with a real service
+		}
+	}
+
+	return sent, nil
+}
+
+// isEventStream checks if a request is for the server-sent event stream of
+// the event service by a client that is logged in.
+func (s *Server) isEventStream(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eventService := s.resources[s.eventServiceURI()]
+	uri, _ := eventService["ServerSentEventUri"].(string)
+	return uri != "" && normalize(r.URL.Path) == normalize(uri) && s.authorized(r)
+}
+
+// eventServiceURI gets the URI of the event service.
+func (s *Server) eventServiceURI() string {
+	root := s.resources[utils.ServiceRoot]
+	if service, ok := root["EventService"].(map[string]interface{}); ok {
+		if uri, ok := service["@odata.id"].(string); ok {
+			return normalize(uri)
+		}
+	}
+
+	return ""
+}
+
+// streamEvents sends events to a client until it disconnects. The stream is
+// served without holding the lock of the server, so other requests can be
+// made while it is open.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "InternalError")
+		return
+	}
+
+	stream := make(eventStream, 16)
+	s.streamsMu.Lock()
+	if s.streams == nil {
+		s.streams = map[eventStream]bool{}
+	}
+	s.streams[stream] = true
+	s.streamsMu.Unlock()
+
+	defer func() {
+		s.streamsMu.Lock()
+		delete(s.streams, stream)
+		s.streamsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for id := 1; ; id++ {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-stream:
+			_, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", id, data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package mockuptest runs ctlfish commands against a mockup service in tests.
+package mockuptest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/stmcginnis/ctlfish/config"
+	"github.com/stmcginnis/ctlfish/mockup"
+)
+
+const (
+	// Username and Password are the credentials of the test service.
+	Username = "admin"
+	Password = "password"
+)
+
+// Dir gets the directory of the mockup used by the tests.
+func Dir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "testdata", "simple")
+}
+
+// Start serves the test mockup until the test ends, and makes it the default
+// connection. Each call gets a fresh copy of the mockup.
+func Start(t testing.TB) *mockup.Server {
+	t.Helper()
+
+	server, err := mockup.Load(Dir())
+	if err != nil {
+		t.Fatalf("unable to load mockup: %v", err)
+	}
+	server.Username = Username
+	server.Password = Password
+
+	service := httptest.NewServer(server)
+	t.Cleanup(service.Close)
+
+	endpoint, _ := url.Parse(service.URL)
+	host, port, _ := net.SplitHostPort(endpoint.Host)
+
+	configFile := filepath.Join(t.TempDir(), "ctlfish.yaml")
+	settings := fmt.Sprintf(
+		"default: mockup\nsystems:\n  mockup:\n    host: %s\n    port: %s\n    protocol: http\n    username: %s\n    password: %s\n",
+		host, port, Username, Password)
+	err = os.WriteFile(configFile, []byte(settings), 0o600)
+	if err != nil {
+		t.Fatalf("unable to write config: %v", err)
+	}
+	config.InitConfig(configFile)
+
+	return server
+}
+
+// WriteFile writes a file with the given name and content to a directory that
+// is removed when the test ends, returning its path.
+func WriteFile(t testing.TB, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unable to write %s: %v", name, err)
+	}
+
+	return path
+}
+
+// Run executes a command with the given arguments, returning everything it
+// printed. Flags are reset afterwards so commands can be run again.
+func Run(t testing.TB, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+
+	output := &bytes.Buffer{}
+	cmd.SetOut(output)
+	cmd.SetErr(output)
+	cmd.SetIn(strings.NewReader(""))
+	cmd.SetArgs(args)
+	defer resetFlags(cmd)
+
+	err := cmd.Execute()
+	return output.String(), err
+}
+
+// resetFlags puts the flags of a command and its subcommands back to their
+// defaults.
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			values := []string{}
+			if defaults := strings.Trim(flag.DefValue, "[]"); defaults != "" {
+				values = strings.Split(defaults, ",")
+			}
+			_ = slice.Replace(values)
+		} else {
+			_ = flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	})
+
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
@@ -0,0 +1,486 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package mockup serves a mockup directory, in the layout written by the DMTF
+// Redfish-Mockup-Creator and "ctlfish mockup export", as a Redfish service.
+package mockup
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// baseRegistry is the registry used for the messages in error responses.
+const baseRegistry = "Base.1.16.0."
+
+// Server is a Redfish service backed by a mockup. Changes made through PATCH
+// requests, actions, and creating or deleting accounts and event
+// subscriptions are kept in memory only. Events given to SendEvent are sent to
+// the server-sent event stream of the event service.
+type Server struct {
+	// Username and Password are the credentials accepted by the service. If
+	// Username is empty, any credentials are accepted.
+	Username string
+	Password string
+
+	mu        sync.Mutex
+	resources map[string]map[string]interface{}
+	metadata  []byte
+	sessions  map[string]string
+	sessionID int
+
+	streamsMu sync.Mutex
+	streams   map[eventStream]bool
+}
+
+// Load reads the resources of a mockup directory. The directory can be the
+// top of the mockup, containing the redfish directory, or the redfish or
+// redfish/v1 directory itself.
+func Load(dir string) (*Server, error) {
+	switch {
+	case filepath.Base(dir) == "v1" && filepath.Base(filepath.Dir(dir)) == "redfish":
+		dir = filepath.Dir(filepath.Dir(dir))
+	case filepath.Base(dir) == "redfish":
+		dir = filepath.Dir(dir)
+	}
+
+	s := &Server{
+		resources: map[string]map[string]interface{}{},
+		sessions:  map[string]string{},
+	}
+
+	err := filepath.WalkDir(filepath.Join(dir, "redfish"), func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+		uri := normalize("/" + filepath.ToSlash(rel))
+
+		switch entry.Name() {
+		case "index.json":
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			resource := map[string]interface{}{}
+			err = json.Unmarshal(data, &resource)
+			if err != nil {
+				return fmt.Errorf("unable to read %s: %w", file, err)
+			}
+			s.resources[uri] = resource
+		case "index.xml":
+			if uri == utils.ServiceRoot+"/$metadata" {
+				s.metadata, err = os.ReadFile(file)
+			}
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := s.resources[utils.ServiceRoot]; !found {
+		return nil, fmt.Errorf("no service root found in '%s'", dir)
+	}
+
+	return s, nil
+}
+
+// Resource gets a copy of the current state of a resource.
+func (s *Server) Resource(uri string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resource, found := s.resources[normalize(uri)]
+	if !found {
+		return nil, false
+	}
+
+	return deepCopy(resource).(map[string]interface{}), true
+}
+
+// Update merges changes into a resource, as a PATCH request would.
+func (s *Server) Update(uri string, changes map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resource, found := s.resources[normalize(uri)]
+	if !found {
+		return fmt.Errorf("resource '%s' was not found", uri)
+	}
+
+	applyChanges(resource, changes)
+	return nil
+}
+
+// ServeHTTP handles a request to the service.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.isEventStream(r) {
+		s.streamEvents(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uri := normalize(r.URL.Path)
+	w.Header().Set("OData-Version", "4.0")
+
+	if uri == utils.ServiceRoot+"/$metadata" && r.Method == http.MethodGet && s.metadata != nil {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write(s.metadata)
+		return
+	}
+
+	if r.Method == http.MethodPost && uri == s.sessionsURI() {
+		s.createSession(w, r)
+		return
+	}
+
+	if !isPublic(uri) && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "NoValidSession")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		resource, found := s.resources[uri]
+		if !found {
+			writeError(w, http.StatusNotFound, "ResourceMissingAtURI", uri)
+			return
+		}
+		writeResource(w, http.StatusOK, resource)
+	case http.MethodPatch:
+		s.patch(w, r, uri)
+	case http.MethodPost:
+		s.post(w, r, uri)
+	case http.MethodDelete:
+		s.delete(w, uri)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "OperationNotAllowed")
+	}
+}
+
+// sessionsURI gets the URI of the session collection.
+func (s *Server) sessionsURI() string {
+	root := s.resources[utils.ServiceRoot]
+	if links, ok := root["Links"].(map[string]interface{}); ok {
+		if sessions, ok := links["Sessions"].(map[string]interface{}); ok {
+			if uri, ok := sessions["@odata.id"].(string); ok {
+				return normalize(uri)
+			}
+		}
+	}
+
+	return utils.ServiceRoot + "/SessionService/Sessions"
+}
+
+// isPublic checks if a resource can be read without logging in.
+func isPublic(uri string) bool {
+	return uri == "/redfish" || uri == utils.ServiceRoot || uri == utils.ServiceRoot+"/odata"
+}
+
+// authorized checks the session token or basic authentication credentials of
+// a request.
+func (s *Server) authorized(r *http.Request) bool {
+	if token := r.Header.Get("X-Auth-Token"); token != "" {
+		_, found := s.sessions[token]
+		return found
+	}
+
+	username, password, ok := r.BasicAuth()
+	return ok && s.validCredentials(username, password)
+}
+
+// validCredentials checks a user name and password.
+func (s *Server) validCredentials(username, password string) bool {
+	return s.Username == "" || (username == s.Username && password == s.Password)
+}
+
+// createSession logs in, adding a session to the session collection.
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	credentials := struct {
+		UserName string
+		Password string
+	}{}
+	if json.NewDecoder(r.Body).Decode(&credentials) != nil {
+		writeError(w, http.StatusBadRequest, "MalformedJSON")
+		return
+	}
+
+	if !s.validCredentials(credentials.UserName, credentials.Password) {
+		writeError(w, http.StatusUnauthorized, "ResourceAtUriUnauthorized", r.URL.Path)
+		return
+	}
+
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+
+	// Skip any sessions that were captured in the mockup
+	collectionURI := s.sessionsURI()
+	uri := ""
+	for uri == "" || s.resources[uri] != nil {
+		s.sessionID++
+		uri = fmt.Sprintf("%s/%d", collectionURI, s.sessionID)
+	}
+	session := map[string]interface{}{
+		"@odata.id":   uri,
+		"@odata.type": "#Session.v1_0_0.Session",
+		"Id":          strconv.Itoa(s.sessionID),
+		"Name":        "User Session",
+		"UserName":    credentials.UserName,
+	}
+	s.resources[uri] = session
+	s.sessions[hex.EncodeToString(token)] = uri
+	s.addMember(collectionURI, uri)
+
+	w.Header().Set("Location", uri)
+	w.Header().Set("X-Auth-Token", hex.EncodeToString(token))
+	writeResource(w, http.StatusCreated, session)
+}
+
+// delete logs out of a session, or removes a member of a collection that
+// clients can create members in.
+func (s *Server) delete(w http.ResponseWriter, uri string) {
+	for token, session := range s.sessions {
+		if session != uri {
+			continue
+		}
+
+		delete(s.sessions, token)
+		delete(s.resources, uri)
+		s.removeMember(s.sessionsURI(), uri)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if _, found := s.resources[uri]; found {
+		if !s.creatable(path.Dir(uri)) {
+			writeError(w, http.StatusMethodNotAllowed, "ResourceCannotBeDeleted")
+			return
+		}
+
+		delete(s.resources, uri)
+		s.removeMember(path.Dir(uri), uri)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "ResourceMissingAtURI", uri)
+}
+
+// addMember adds a link to a collection, creating the collection if needed.
+func (s *Server) addMember(collectionURI, uri string) {
+	collection, found := s.resources[collectionURI]
+	if !found {
+		collection = map[string]interface{}{
+			"@odata.id": collectionURI,
+			"Name":      path.Base(collectionURI),
+			"Members":   []interface{}{},
+		}
+		s.resources[collectionURI] = collection
+	}
+
+	members, _ := collection["Members"].([]interface{})
+	members = append(members, map[string]interface{}{"@odata.id": uri})
+	collection["Members"] = members
+	collection["Members@odata.count"] = len(members)
+}
+
+// removeMember removes a link from a collection.
+func (s *Server) removeMember(collectionURI, uri string) {
+	collection, found := s.resources[collectionURI]
+	if !found {
+		return
+	}
+
+	members, _ := collection["Members"].([]interface{})
+	kept := []interface{}{}
+	for _, member := range members {
+		if link, ok := member.(map[string]interface{}); ok && link["@odata.id"] == uri {
+			continue
+		}
+		kept = append(kept, member)
+	}
+	collection["Members"] = kept
+	collection["Members@odata.count"] = len(kept)
+}
+
+// patch merges the request body into a resource.
+func (s *Server) patch(w http.ResponseWriter, r *http.Request, uri string) {
+	resource, found := s.resources[uri]
+	if !found {
+		writeError(w, http.StatusNotFound, "ResourceMissingAtURI", uri)
+		return
+	}
+
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != etag(resource) {
+		writeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+		return
+	}
+
+	changes := map[string]interface{}{}
+	if json.NewDecoder(r.Body).Decode(&changes) != nil {
+		writeError(w, http.StatusBadRequest, "MalformedJSON")
+		return
+	}
+
+	for _, key := range utils.SortedKeys(changes) {
+		if _, found := resource[key]; (!found && key != "Password") || strings.HasPrefix(key, "@odata.") {
+			writeError(w, http.StatusBadRequest, "PropertyUnknown", key)
+			return
+		}
+	}
+
+	applyChanges(resource, changes)
+	writeResource(w, http.StatusOK, resource)
+}
+
+// applyChanges merges changes into a resource and updates its ETag.
+func applyChanges(resource, changes map[string]interface{}) {
+	for key, value := range changes {
+		if key == "Password" {
+			// Passwords are accepted but never shown
+			continue
+		}
+		resource[key] = merge(resource[key], value)
+	}
+
+	if _, found := resource["@odata.etag"]; found {
+		delete(resource, "@odata.etag")
+		resource["@odata.etag"] = etag(resource)
+	}
+}
+
+// merge applies a PATCH value to an existing value. Objects are merged and
+// array members are updated by position, where an empty object leaves a
+// member unchanged and null removes it.
+func merge(current, change interface{}) interface{} {
+	switch c := change.(type) {
+	case map[string]interface{}:
+		existing, ok := current.(map[string]interface{})
+		if !ok {
+			return change
+		}
+		for key, value := range c {
+			existing[key] = merge(existing[key], value)
+		}
+		return existing
+	case []interface{}:
+		existing, ok := current.([]interface{})
+		if !ok {
+			return change
+		}
+
+		result := []interface{}{}
+		for i := 0; i < len(existing) || i < len(c); i++ {
+			switch {
+			case i >= len(c):
+				result = append(result, existing[i])
+			case c[i] == nil:
+				continue
+			case i >= len(existing):
+				result = append(result, c[i])
+			default:
+				result = append(result, merge(existing[i], c[i]))
+			}
+		}
+		return result
+	}
+
+	return change
+}
+
+// etag gets the ETag of a resource, either its @odata.etag or one based on
+// its contents.
+func etag(resource map[string]interface{}) string {
+	if value, ok := resource["@odata.etag"].(string); ok {
+		return value
+	}
+
+	data, _ := json.Marshal(resource)
+	return fmt.Sprintf(`W/"%08x"`, crc32.ChecksumIEEE(data))
+}
+
+// writeResource sends a resource as the response.
+func writeResource(w http.ResponseWriter, status int, resource map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(resource))
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resource)
+}
+
+// writeError sends a Redfish error response using a message from the Base
+// registry.
+func writeError(w http.ResponseWriter, status int, message string, args ...string) {
+	messageID := baseRegistry + message
+	info := map[string]interface{}{
+		"@odata.type": "#Message.v1_1_1.Message",
+		"MessageId":   messageID,
+		"MessageArgs": args,
+	}
+
+	if resolved, ok := utils.NewMessageRegistries(nil).Resolve(messageID, args); ok {
+		info["Message"] = resolved.Message
+		info["Severity"] = resolved.Severity
+		info["Resolution"] = resolved.Resolution
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":                  baseRegistry + "GeneralError",
+			"message":               "A general error has occurred. See ExtendedInfo for more information.",
+			"@Message.ExtendedInfo": []interface{}{info},
+		},
+	})
+}
+
+// normalize gets the canonical form of a URI path.
+func normalize(uri string) string {
+	uri = path.Clean("/" + uri)
+	if uri == "/" {
+		return uri
+	}
+
+	return strings.TrimSuffix(uri, "/")
+}
+
+// deepCopy copies decoded JSON.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = deepCopy(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = deepCopy(item)
+		}
+		return result
+	}
+
+	return value
+}
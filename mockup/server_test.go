@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package mockup
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newServer loads the test mockup with credentials required.
+func newServer(t *testing.T) *Server {
+	t.Helper()
+
+	server, err := Load(filepath.Join("testdata", "simple"))
+	if err != nil {
+		t.Fatalf("unable to load mockup: %v", err)
+	}
+	server.Username = "admin"
+	server.Password = "password"
+
+	return server
+}
+
+// request creates a request using basic authentication.
+func request(method, uri, body string) *http.Request {
+	r := httptest.NewRequest(method, uri, strings.NewReader(body))
+	r.SetBasicAuth("admin", "password")
+	return r
+}
+
+// anonymous creates a request without credentials.
+func anonymous(method, uri, body string) *http.Request {
+	return httptest.NewRequest(method, uri, strings.NewReader(body))
+}
+
+// send makes a request to the server.
+func send(server *Server, r *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, r)
+	return recorder
+}
+
+func TestLoad(t *testing.T) {
+	for _, dir := range []string{"simple", "simple/redfish", "simple/redfish/v1"} {
+		server, err := Load(filepath.Join("testdata", filepath.FromSlash(dir)))
+		if err != nil {
+			t.Fatalf("unable to load %s: %v", dir, err)
+		}
+
+		if _, found := server.Resource("/redfish/v1/Systems/1/"); !found {
+			t.Errorf("system was not loaded from %s", dir)
+		}
+	}
+
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("expected an error loading an empty directory")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	server := newServer(t)
+
+	if code := send(server, anonymous(http.MethodGet, "/redfish/v1/", "")).Code; code != http.StatusOK {
+		t.Errorf("service root should not need authentication, got %d", code)
+	}
+
+	if code := send(server, anonymous(http.MethodGet, "/redfish/v1/Systems", "")).Code; code != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized, got %d", code)
+	}
+
+	resp := send(server, anonymous(http.MethodPost, "/redfish/v1/SessionService/Sessions",
+		`{"UserName": "admin", "Password": "wrong"}`))
+	if resp.Code != http.StatusUnauthorized {
+		t.Errorf("expected login to fail, got %d", resp.Code)
+	}
+
+	resp = send(server, anonymous(http.MethodPost, "/redfish/v1/SessionService/Sessions",
+		`{"UserName": "admin", "Password": "password"}`))
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("expected login to succeed, got %d: %s", resp.Code, resp.Body)
+	}
+
+	token := resp.Header().Get("X-Auth-Token")
+	withToken := func(method, uri string) *http.Request {
+		r := anonymous(method, uri, "")
+		r.Header.Set("X-Auth-Token", token)
+		return r
+	}
+
+	if code := send(server, withToken(http.MethodGet, "/redfish/v1/Systems")).Code; code != http.StatusOK {
+		t.Errorf("expected the session to be accepted, got %d", code)
+	}
+
+	if code := send(server, withToken(http.MethodDelete, resp.Header().Get("Location"))).Code; code != http.StatusNoContent {
+		t.Errorf("expected logout to succeed, got %d", code)
+	}
+
+	if code := send(server, withToken(http.MethodGet, "/redfish/v1/Systems")).Code; code != http.StatusUnauthorized {
+		t.Errorf("expected the session to be gone, got %d", code)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	server := newServer(t)
+	uri := "/redfish/v1/Chassis/1/Power"
+
+	resp := send(server, request(http.MethodPatch, uri, `{"PowerControl": [{"PowerLimit": {"LimitInWatts": null}}]}`))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("patch failed with %d: %s", resp.Code, resp.Body)
+	}
+
+	power, _ := server.Resource(uri)
+	control := power["PowerControl"].([]interface{})[0].(map[string]interface{})
+	limit := control["PowerLimit"].(map[string]interface{})
+	if limit["LimitInWatts"] != nil || limit["LimitException"] != "LogEventOnly" || control["PowerCapacityWatts"] != float64(800) {
+		t.Errorf("changes were not merged: %v", control)
+	}
+
+	if code := send(server, request(http.MethodPatch, uri, `{"Bogus": 1}`)).Code; code != http.StatusBadRequest {
+		t.Errorf("expected unknown property to be rejected, got %d", code)
+	}
+}
+
+func TestPatchETag(t *testing.T) {
+	server := newServer(t)
+	uri := "/redfish/v1/Systems/1"
+	etag := send(server, request(http.MethodGet, uri, "")).Header().Get("ETag")
+
+	stale := request(http.MethodPatch, uri, `{"IndicatorLED": "Lit"}`)
+	stale.Header.Set("If-Match", `W/"0"`)
+	if code := send(server, stale).Code; code != http.StatusPreconditionFailed {
+		t.Errorf("expected stale ETag to be rejected, got %d", code)
+	}
+
+	current := request(http.MethodPatch, uri, `{"IndicatorLED": "Lit"}`)
+	current.Header.Set("If-Match", etag)
+	if code := send(server, current).Code; code != http.StatusOK {
+		t.Errorf("expected current ETag to be accepted, got %d", code)
+	}
+}
+
+func TestReset(t *testing.T) {
+	server := newServer(t)
+	target := "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"
+
+	if code := send(server, request(http.MethodPost, target, `{"ResetType": "Nmi"}`)).Code; code != http.StatusBadRequest {
+		t.Errorf("expected reset type outside the allowed values to be rejected, got %d", code)
+	}
+
+	if code := send(server, request(http.MethodPost, target, `{"ResetType": "ForceOff"}`)).Code; code != http.StatusNoContent {
+		t.Fatalf("reset failed with %d", code)
+	}
+
+	system, _ := server.Resource("/redfish/v1/Systems/1")
+	if system["PowerState"] != "Off" {
+		t.Errorf("expected the system to be off, got %v", system["PowerState"])
+	}
+
+	if code := send(server, request(http.MethodPost, "/redfish/v1/Systems/1", `{}`)).Code; code != http.StatusMethodNotAllowed {
+		t.Errorf("expected post to a resource to be rejected, got %d", code)
+	}
+}
+
+func TestCreateAndDelete(t *testing.T) {
+	server := newServer(t)
+	accounts := "/redfish/v1/AccountService/Accounts"
+
+	if code := send(server, request(http.MethodPost, accounts, `{"UserName": "deploy", "RoleId": "Operator"}`)).Code; code != http.StatusBadRequest {
+		t.Errorf("expected an account without a password to be rejected, got %d", code)
+	}
+
+	resp := send(server, request(http.MethodPost, accounts, `{"UserName": "deploy", "Password": "secret123", "RoleId": "Operator"}`))
+	if resp.Code != http.StatusCreated || resp.Header().Get("Location") != accounts+"/3" {
+		t.Fatalf("create failed with %d at %q: %s", resp.Code, resp.Header().Get("Location"), resp.Body)
+	}
+
+	account, _ := server.Resource(accounts + "/3")
+	if account["UserName"] != "deploy" || account["Enabled"] != true || account["Password"] != nil {
+		t.Errorf("unexpected account: %v", account)
+	}
+
+	collection, _ := server.Resource(accounts)
+	if collection["Members@odata.count"] != 3 {
+		t.Errorf("expected the account to be listed, got %v", collection["Members"])
+	}
+
+	if code := send(server, request(http.MethodDelete, accounts+"/3", "")).Code; code != http.StatusNoContent {
+		t.Fatalf("delete failed with %d", code)
+	}
+	if _, found := server.Resource(accounts + "/3"); found {
+		t.Errorf("expected the account to be gone")
+	}
+
+	if code := send(server, request(http.MethodDelete, "/redfish/v1/Systems/1", "")).Code; code != http.StatusMethodNotAllowed {
+		t.Errorf("expected delete of a system to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BackupVersion is the version of the backup format that is written. It is
+// increased when a change to the format means older versions of ctlfish would
+// restore a backup incorrectly.
+const BackupVersion = 1
+
+// BackupSections are the sections saved in a backup, which are the settings
+// that can be restored and belong to the configuration of a node rather than
+// its current state.
+var BackupSections = []string{
+	BIOS, Boot, NetworkProtocols, NTP, DNS, AccountPolicy, Users, LDAP, Subscriptions, PowerLimit, AssetTag,
+}
+
+// Backup is a snapshot of the settings of a service.
+type Backup struct {
+	Version int `yaml:"version"`
+	// Source is the connection the settings were read from.
+	Source  string    `yaml:"source"`
+	Created time.Time `yaml:"created"`
+	// Settings holds the settings, which never include passwords.
+	Settings Settings `yaml:"settings"`
+}
+
+// NewBackup creates a backup of settings read from a connection.
+func NewBackup(source string, s Settings) *Backup {
+	return &Backup{
+		Version:  BackupVersion,
+		Source:   source,
+		Created:  time.Now().UTC().Truncate(time.Second),
+		Settings: s,
+	}
+}
+
+// Write saves the backup as YAML.
+func (b *Backup) Write(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(b); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// LoadBackup reads a backup file, checking it is a version that can be
+// restored.
+func LoadBackup(path string) (*Backup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{}
+	err = yaml.Unmarshal(data, backup)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %v", path, err)
+	}
+
+	switch {
+	case backup.Version == 0:
+		return nil, fmt.Errorf("'%s' is not a backup", path)
+	case backup.Version > BackupVersion:
+		return nil, fmt.Errorf(
+			"'%s' is a version %d backup, which needs a newer version of ctlfish to restore", path, backup.Version)
+	}
+
+	normalized, _ := normalize(map[string]interface{}(backup.Settings)).(map[string]interface{})
+	backup.Settings = Settings(normalized)
+	if backup.Settings == nil {
+		backup.Settings = Settings{}
+	}
+	if err := backup.Settings.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings in '%s': %v", path, err)
+	}
+
+	return backup, nil
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	backup := NewBackup("web01", Settings{
+		BIOS:     map[string]interface{}{"BootMode": "Uefi", "SerialConsoleBaudRate": float64(115200)},
+		AssetTag: "RACK-A1-U12",
+	})
+
+	data := &bytes.Buffer{}
+	if err := backup.Write(data); err != nil {
+		t.Fatalf("unable to write backup: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.yaml")
+	if err := os.WriteFile(path, data.Bytes(), 0o600); err != nil {
+		t.Fatalf("unable to save backup: %v", err)
+	}
+
+	loaded, err := LoadBackup(path)
+	if err != nil {
+		t.Fatalf("unable to load backup: %v\n%s", err, data)
+	}
+
+	if loaded.Version != BackupVersion || loaded.Source != "web01" || !loaded.Created.Equal(backup.Created) {
+		t.Errorf("unexpected backup details: %+v", loaded)
+	}
+	if !reflect.DeepEqual(loaded.Settings, backup.Settings) {
+		t.Errorf("expected settings %v, got %v", backup.Settings, loaded.Settings)
+	}
+}
+
+func TestLoadBackupInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"not a backup", "bios:\n  BootMode: Uefi\n", "is not a backup"},
+		{"newer version", "version: 99\nsettings: {}\n", "is a version 99 backup"},
+		{"unknown section", "version: 1\nsettings:\n  bogus: {}\n", "unknown section 'bogus'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "backup.yaml")
+			if err := os.WriteFile(path, []byte(test.content), 0o600); err != nil {
+				t.Fatalf("unable to save backup: %v", err)
+			}
+
+			_, err := LoadBackup(path)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected error containing %q, got: %v", test.expected, err)
+			}
+		})
+	}
+}
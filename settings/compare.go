@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// NotSet is the value of a setting that is missing on one side of a
+// difference.
+var NotSet = notSet{}
+
+type notSet struct{}
+
+func (notSet) String() string {
+	return "(not set)"
+}
+
+// Difference is a setting that has a different value on each side.
+type Difference struct {
+	// Path is the section and property names of the setting, separated by
+	// dots, such as bios.BootMode.
+	Path  string
+	Left  interface{}
+	Right interface{}
+}
+
+// complete are the sections where entries that are only on the right are
+// reported even when only checking the settings on the left, as an unexpected
+// entry, such as an extra user, matters as much as a wrong value. The entries
+// on the left are still only checked for the properties they list.
+var complete = map[string]bool{Users: true}
+
+// Compare finds the settings that differ. If partial is set, only the
+// settings on the left are checked, as when checking against a baseline.
+// Otherwise settings on either side are compared.
+func Compare(left, right Settings, partial bool) []Difference {
+	differences := []Difference{}
+	l, _ := normalize(map[string]interface{}(left)).(map[string]interface{})
+	r, _ := normalize(map[string]interface{}(right)).(map[string]interface{})
+
+	for _, name := range orderedKeys(l, r, partial) {
+		left, right := lookup(l, name), lookup(r, name)
+		compareValues(&differences, name, left, right, partial)
+		if partial && complete[name] {
+			extraEntries(&differences, name, left, right)
+		}
+	}
+
+	return differences
+}
+
+// extraEntries reports the entries of a section that are only on the right.
+func extraEntries(differences *[]Difference, section string, left, right interface{}) {
+	leftObject, _ := left.(map[string]interface{})
+	rightObject, _ := right.(map[string]interface{})
+	for _, key := range utils.SortedKeys(rightObject) {
+		if _, ok := leftObject[key]; !ok {
+			*differences = append(*differences, Difference{Path: section + "." + key, Left: NotSet, Right: rightObject[key]})
+		}
+	}
+}
+
+// lookup gets a property of an object, or NotSet if it is missing.
+func lookup(object map[string]interface{}, key string) interface{} {
+	value, ok := object[key]
+	if !ok {
+		return NotSet
+	}
+
+	return value
+}
+
+// orderedKeys gets the section names to compare, in order.
+func orderedKeys(left, right map[string]interface{}, partial bool) []string {
+	names := Settings(left).Names()
+	if partial {
+		return names
+	}
+
+	for _, name := range Settings(right).Names() {
+		if _, ok := left[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// compareValues compares two values, going into objects to find the
+// properties that differ. Other values, including lists, are compared whole,
+// as are objects below a section that are only on one side, such as a user.
+func compareValues(differences *[]Difference, path string, left, right interface{}, partial bool) {
+	leftObject, leftIsObject := left.(map[string]interface{})
+	rightObject, rightIsObject := right.(map[string]interface{})
+
+	section := !strings.Contains(path, ".")
+	if (leftIsObject && rightIsObject) || (section && (leftIsObject || rightIsObject)) {
+		for _, key := range utils.SortedKeys(leftObject) {
+			compareValues(differences, path+"."+key, leftObject[key], lookup(rightObject, key), partial)
+		}
+
+		if !partial {
+			for _, key := range utils.SortedKeys(rightObject) {
+				if _, ok := leftObject[key]; !ok {
+					compareValues(differences, path+"."+key, NotSet, rightObject[key], partial)
+				}
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		*differences = append(*differences, Difference{Path: path, Left: left, Right: right})
+	}
+}
+
+// FormatValue formats a setting for display. Lists are joined with commas and
+// objects are shown as JSON.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case notSet:
+		return v.String()
+	case []interface{}:
+		items := []string{}
+		for _, item := range v {
+			items = append(items, FormatValue(item))
+		}
+		return strings.Join(items, ", ")
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
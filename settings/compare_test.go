@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	current := Settings{
+		BIOS:  map[string]interface{}{"BootMode": "Uefi", "ProcTurboMode": "Enabled"},
+		NTP:   map[string]interface{}{"ProtocolEnabled": true, "NTPServers": []interface{}{"a", "b"}},
+		Users: map[string]interface{}{"admin": map[string]interface{}{"RoleId": "Administrator"}, "guest": map[string]interface{}{"RoleId": "ReadOnly"}},
+		AccountPolicy: map[string]interface{}{
+			"MinPasswordLength": float64(8),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		left     Settings
+		partial  bool
+		expected []Difference
+	}{
+		{
+			name:     "same",
+			left:     current,
+			expected: []Difference{},
+		},
+		{
+			// Numbers read from YAML are ints, but match the float64 from JSON
+			name: "baseline",
+			left: Settings{
+				BIOS:          map[string]interface{}{"BootMode": "Uefi"},
+				AccountPolicy: map[string]interface{}{"MinPasswordLength": 8},
+				NTP:           map[string]interface{}{"NTPServers": []interface{}{"b", "a"}},
+			},
+			partial: true,
+			expected: []Difference{
+				{Path: "ntp.NTPServers", Left: []interface{}{"b", "a"}, Right: []interface{}{"a", "b"}},
+			},
+		},
+		{
+			// Unexpected users are reported even when checking a baseline,
+			// but listed users are only checked for the listed properties
+			name:    "extra user",
+			left:    Settings{Users: map[string]interface{}{"admin": map[string]interface{}{}}},
+			partial: true,
+			expected: []Difference{
+				{Path: "users.guest", Left: NotSet, Right: map[string]interface{}{"RoleId": "ReadOnly"}},
+			},
+		},
+		{
+			name:    "missing section",
+			left:    Settings{Boot: map[string]interface{}{"BootSourceOverrideEnabled": "Disabled"}},
+			partial: true,
+			expected: []Difference{
+				{Path: "boot.BootSourceOverrideEnabled", Left: "Disabled", Right: NotSet},
+			},
+		},
+		{
+			name: "both sides",
+			left: Settings{
+				BIOS:  map[string]interface{}{"BootMode": "Legacy", "ProcTurboMode": "Enabled"},
+				NTP:   current[NTP],
+				Users: current[Users],
+				AccountPolicy: map[string]interface{}{
+					"MinPasswordLength": float64(8),
+					"MaxPasswordLength": float64(20),
+				},
+			},
+			expected: []Difference{
+				{Path: "bios.BootMode", Left: "Legacy", Right: "Uefi"},
+				{Path: "account_policy.MaxPasswordLength", Left: float64(20), Right: NotSet},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			differences := Compare(test.left, current, test.partial)
+			if !reflect.DeepEqual(differences, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, differences)
+			}
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for value, expected := range map[interface{}]string{
+		"Uefi":      "Uefi",
+		true:        "true",
+		float64(30): "30",
+		NotSet:      "(not set)",
+	} {
+		if actual := FormatValue(value); actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+	}
+
+	if actual := FormatValue([]interface{}{"a", "b"}); actual != "a, b" {
+		t.Errorf("expected list to be joined, got %q", actual)
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads settings from a YAML or JSON file.
+func Load(path string) (Settings, error) {
+	document, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := Settings(document)
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings in '%s': %v", path, err)
+	}
+
+	return s, nil
+}
+
+// Manifest is the desired settings of connections.
+type Manifest struct {
+	// Targets are the connections and groups the settings are for, if the
+	// manifest names them.
+	Targets  []string
+	Settings Settings
+}
+
+// targetsKey is the entry of a manifest that names the connections and
+// groups it is for, which may be a single name or a list.
+const targetsKey = "targets"
+
+// LoadManifest reads a manifest from a YAML or JSON file. It holds settings
+// like those read by Load, and may also name the connections and groups they
+// are for.
+func LoadManifest(path string) (*Manifest, error) {
+	document, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Settings: Settings(document)}
+	switch targets := document[targetsKey].(type) {
+	case nil:
+	case string:
+		manifest.Targets = []string{targets}
+	case []interface{}:
+		for _, target := range targets {
+			name, ok := target.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid targets in '%s': '%v' is not a name", path, target)
+			}
+			manifest.Targets = append(manifest.Targets, name)
+		}
+	default:
+		return nil, fmt.Errorf("invalid targets in '%s': must be a name or a list of names", path)
+	}
+	delete(manifest.Settings, targetsKey)
+
+	if err := manifest.Settings.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings in '%s': %v", path, err)
+	}
+
+	return manifest, nil
+}
+
+// parse reads a YAML or JSON file as decoded JSON.
+func parse(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{}
+	err = yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse '%s': %v", path, err)
+	}
+
+	result, _ := normalize(document).(map[string]interface{})
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,583 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Step is a request that changes the settings of a service.
+type Step struct {
+	// Section is the name of the section the step changes.
+	Section string
+	// Method is the HTTP method of the request: PATCH, POST or DELETE.
+	Method string
+	URI    string
+	// Body is the body of the request. It may hold passwords, so it should
+	// not be shown.
+	Body interface{}
+	// Changes are the settings the step changes, with the desired value on
+	// the left and the current value on the right.
+	Changes []Difference
+	// Reboot is set if the change only takes effect once the system is reset.
+	Reboot bool
+}
+
+// Apply makes the request of the step.
+func (s *Step) Apply(c common.Client) error {
+	var resp *http.Response
+	var err error
+
+	switch s.Method {
+	case http.MethodPatch:
+		resp, err = c.Patch(s.URI, s.Body)
+	case http.MethodPost:
+		resp, err = c.Post(s.URI, s.Body)
+	case http.MethodDelete:
+		resp, err = c.Delete(s.URI)
+	default:
+		return fmt.Errorf("unsupported method %s", s.Method)
+	}
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// planner works out the steps to change a section from its current value to
+// the desired one.
+type planner func(s *service, desired, current interface{}) ([]*Step, error)
+
+// Skipped is a section, or an entry of one, that was left out of a plan as the
+// service cannot take it.
+type Skipped struct {
+	Path   string
+	Reason string
+}
+
+// Plan works out the steps to change a service to the desired settings. Only
+// settings that differ from the current ones are changed, so once the steps
+// are applied, planning the same settings again gives no steps.
+func Plan(c common.Client, desired Settings) ([]*Step, error) {
+	steps, _, err := plan(newService(c), desired)
+	return steps, err
+}
+
+// PlanAvailable is like Plan, but skips the sections and entries the service
+// cannot take, such as the power limit of a chassis it does not have, so the
+// rest can still be applied. This is for restoring to a node that replaces
+// another. What was skipped is returned with the reasons.
+func PlanAvailable(c common.Client, desired Settings) ([]*Step, []Skipped, error) {
+	svc := newService(c)
+	svc.skipUnavailable = true
+	return plan(svc, desired)
+}
+
+// plan works out the steps to change a service to the desired settings.
+func plan(svc *service, desired Settings) ([]*Step, []Skipped, error) {
+	err := desired.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	steps := []*Step{}
+	for _, s := range sections {
+		value, ok := desired[s.name]
+		if !ok {
+			continue
+		}
+		if s.plan == nil {
+			return nil, nil, fmt.Errorf("the %s section cannot be applied", s.name)
+		}
+
+		current, err := s.read(svc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read %s: %s", s.name, utils.ErrorMessage(err))
+		}
+
+		sectionSteps, err := s.plan(svc, normalize(value), normalize(current))
+		if errors.Is(err, errNotSupported) {
+			err = svc.unavailable(s.name, err)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to plan %s: %v", s.name, err)
+		}
+
+		for _, step := range sectionSteps {
+			step.Section = s.name
+		}
+		steps = append(steps, sectionSteps...)
+	}
+
+	return steps, svc.skipped, nil
+}
+
+// errNotSupported is the error for a section the service does not have.
+var errNotSupported = fmt.Errorf("the service does not support these settings")
+
+// unavailable handles settings the service cannot take, returning the error
+// unless they are being skipped.
+func (s *service) unavailable(path string, err error) error {
+	if !s.skipUnavailable {
+		return err
+	}
+
+	s.skipped = append(s.skipped, Skipped{Path: path, Reason: err.Error()})
+	return nil
+}
+
+// object gets a section that must be an object.
+func object(value interface{}) (map[string]interface{}, error) {
+	result, ok := value.(map[string]interface{})
+	if !ok && value != nil {
+		return nil, fmt.Errorf("expected properties, got '%s'", FormatValue(value))
+	}
+
+	return result, nil
+}
+
+// patch gets the body of a PATCH request that gives the current value the
+// desired properties, and whether anything needs to change. Lists are padded
+// with nulls to remove extra items, as PATCH updates lists by position.
+func patch(desired, current interface{}) (interface{}, bool) {
+	desiredObject, ok := desired.(map[string]interface{})
+	if !ok {
+		if reflect.DeepEqual(desired, current) {
+			return nil, false
+		}
+
+		list, isList := desired.([]interface{})
+		existing, _ := current.([]interface{})
+		if isList && len(existing) > len(list) {
+			padded := append([]interface{}{}, list...)
+			for len(padded) < len(existing) {
+				padded = append(padded, nil)
+			}
+			return padded, true
+		}
+
+		return desired, true
+	}
+
+	currentObject, _ := current.(map[string]interface{})
+	body := map[string]interface{}{}
+	for key, value := range desiredObject {
+		if change, changed := patch(value, currentObject[key]); changed {
+			body[key] = change
+		}
+	}
+
+	return body, len(body) > 0
+}
+
+// changes gets the settings below a path that differ from the current ones.
+func changes(path string, desired, current interface{}) []Difference {
+	differences := []Difference{}
+	compareValues(&differences, path, desired, current, true)
+	return differences
+}
+
+// update gets a step that patches the desired properties into a resource, or
+// none if it already has them. If property is set, the changes are made to
+// that property of the resource.
+func update(path, uri, property string, desired, current interface{}) []*Step {
+	body, changed := patch(desired, current)
+	if !changed {
+		return nil
+	}
+	if property != "" {
+		body = map[string]interface{}{property: body}
+	}
+
+	return []*Step{{
+		Method:  http.MethodPatch,
+		URI:     uri,
+		Body:    body,
+		Changes: changes(path, desired, current),
+	}}
+}
+
+// planBIOS patches the BIOS attributes. Services that apply BIOS changes on
+// the next reset have a separate settings resource for the pending values,
+// which are taken into account so changes are not made again. Read-only
+// attributes are left out, as services reject any attempt to change them.
+func (s *service) planBIOS(desired, current interface{}) ([]*Step, error) {
+	desiredAttributes, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := s.system()
+	if err != nil {
+		return nil, err
+	}
+	bios, err := s.get(system.Link("Bios"))
+	if err != nil || bios == nil || current == nil {
+		return nil, errNotSupported
+	}
+
+	// Backups hold read-only attributes such as the service tag, which differ
+	// when restoring to another node
+	readOnly := s.readOnlyAttributes(bios)
+	attributes := map[string]interface{}{}
+	for name, value := range desiredAttributes {
+		if !readOnly[name] {
+			attributes[name] = value
+		}
+	}
+
+	uri := bios.ID()
+	pendingSettings, _ := bios["@Redfish.Settings"].(map[string]interface{})
+	if settingsURI := utils.LinkURI(pendingSettings["SettingsObject"]); settingsURI != "" {
+		uri = settingsURI
+		pending, err := s.get(settingsURI)
+		if err != nil {
+			return nil, err
+		}
+
+		values, _ := normalize(pending["Attributes"]).(map[string]interface{})
+		effective, _ := normalize(current).(map[string]interface{})
+		for name, value := range values {
+			effective[name] = value
+		}
+		current = effective
+	}
+
+	steps := update(BIOS, uri, "Attributes", attributes, current)
+	for _, step := range steps {
+		step.Reboot = true
+	}
+
+	return steps, nil
+}
+
+func (s *service) planBoot(desired, _ interface{}) ([]*Step, error) {
+	boot, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	system, err := s.system()
+	if err != nil || system == nil {
+		return nil, errNotSupported
+	}
+
+	return update(Boot, system.ID(), "Boot", boot, normalize(system["Boot"])), nil
+}
+
+func (s *service) planNetworkProtocols(desired, _ interface{}) ([]*Step, error) {
+	protocols, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	networkProtocol, err := s.networkProtocol()
+	if err != nil || networkProtocol == nil {
+		return nil, errNotSupported
+	}
+
+	return update(NetworkProtocols, networkProtocol.ID(), "", protocols, normalize(networkProtocol)), nil
+}
+
+func (s *service) planNTP(desired, _ interface{}) ([]*Step, error) {
+	ntp, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	networkProtocol, err := s.networkProtocol()
+	if err != nil || networkProtocol == nil {
+		return nil, errNotSupported
+	}
+
+	return update(NTP, networkProtocol.ID(), "NTP", ntp, normalize(networkProtocol["NTP"])), nil
+}
+
+// planDNS patches the host name into the network protocol settings, and the
+// name servers into the first network interface of the manager.
+func (s *service) planDNS(desired, _ interface{}) ([]*Step, error) {
+	dns, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []*Step{}
+	if hostName, ok := dns["HostName"]; ok {
+		networkProtocol, err := s.networkProtocol()
+		if err != nil || networkProtocol == nil {
+			return nil, errNotSupported
+		}
+		steps = append(steps, update(DNS, networkProtocol.ID(), "",
+			map[string]interface{}{"HostName": hostName}, normalize(networkProtocol))...)
+	}
+
+	servers := map[string]interface{}{}
+	for name, value := range dns {
+		if name != "HostName" {
+			servers[name] = value
+		}
+	}
+	if len(servers) > 0 {
+		nic, err := s.managerInterface()
+		if err != nil || nic == nil {
+			return nil, errNotSupported
+		}
+		steps = append(steps, update(DNS, nic.ID(), "", servers, normalize(nic))...)
+	}
+
+	return steps, nil
+}
+
+func (s *service) planAccountPolicy(desired, _ interface{}) ([]*Step, error) {
+	policy, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountService()
+	if err != nil || accounts == nil {
+		return nil, errNotSupported
+	}
+
+	return update(AccountPolicy, accounts.ID(), "", policy, normalize(accounts)), nil
+}
+
+// planUsers updates existing accounts, creates missing ones and deletes those
+// set to null. Accounts that are not listed are left alone. A password is
+// only used when creating an account, as the current one cannot be read to
+// check if it differs.
+func (s *service) planUsers(desired, _ interface{}) ([]*Step, error) {
+	users, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, collectionURI, err := s.accounts()
+	if err != nil {
+		return nil, err
+	}
+	if collectionURI == "" {
+		return nil, errNotSupported
+	}
+
+	steps := []*Step{}
+	for _, name := range utils.SortedKeys(users) {
+		path := Users + "." + name
+		account, exists := accounts[name]
+
+		if users[name] == nil {
+			if exists {
+				steps = append(steps, remove(path, account.ID(), subset(account, userProperties...)))
+			}
+			continue
+		}
+
+		properties, ok := users[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected properties for user '%s', got '%s'", name, FormatValue(users[name]))
+		}
+		properties = without(properties, "Password")
+
+		if exists {
+			steps = append(steps, update(path, account.ID(), "", properties, normalize(account))...)
+			continue
+		}
+
+		password, _ := users[name].(map[string]interface{})["Password"].(string)
+		if password == "" {
+			return nil, fmt.Errorf("user '%s' does not exist, a Password is needed to create it", name)
+		}
+
+		body := without(properties)
+		body["UserName"] = name
+		body["Password"] = password
+		steps = append(steps, create(path, collectionURI, body, properties))
+	}
+
+	return steps, nil
+}
+
+// planLDAP patches the LDAP service. The password of the LDAP service is
+// only sent if given, as the current one cannot be read to check if it
+// differs.
+func (s *service) planLDAP(desired, _ interface{}) ([]*Step, error) {
+	ldap, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.accountService()
+	if err != nil || accounts == nil || accounts["LDAP"] == nil {
+		return nil, errNotSupported
+	}
+
+	return update(LDAP, accounts.ID(), "LDAP", ldap, normalize(accounts["LDAP"])), nil
+}
+
+// planSubscriptions creates missing event subscriptions and deletes those set
+// to null. Only the context of a subscription can be changed, so a
+// subscription with other differences is deleted and created again.
+// Subscriptions that are not listed are left alone.
+func (s *service) planSubscriptions(desired, _ interface{}) ([]*Step, error) {
+	destinations, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, collectionURI, err := s.eventSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	if collectionURI == "" {
+		return nil, errNotSupported
+	}
+
+	steps := []*Step{}
+	for _, destination := range utils.SortedKeys(destinations) {
+		path := Subscriptions + "." + destination
+		subscription, exists := subscriptions[destination]
+
+		if destinations[destination] == nil {
+			if exists {
+				steps = append(steps, remove(path, subscription.ID(), subset(subscription, subscriptionProperties...)))
+			}
+			continue
+		}
+
+		properties, ok := destinations[destination].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected properties for '%s', got '%s'", destination, FormatValue(destinations[destination]))
+		}
+
+		if exists {
+			current := normalize(subscription)
+			body, changed := patch(properties, current)
+			if !changed {
+				continue
+			}
+			if changed := utils.SortedKeys(body.(map[string]interface{})); len(changed) == 1 && changed[0] == "Context" {
+				steps = append(steps, update(path, subscription.ID(), "", properties, current)...)
+				continue
+			}
+			steps = append(steps, remove(path, subscription.ID(), subset(subscription, subscriptionProperties...)))
+		}
+
+		body := without(properties)
+		body["Destination"] = destination
+		if _, ok := body["Protocol"]; !ok {
+			body["Protocol"] = "Redfish"
+		}
+		steps = append(steps, create(path, collectionURI, body, properties))
+	}
+
+	return steps, nil
+}
+
+// planPowerLimits patches the power limit of each chassis, in the resource
+// the limit was read from.
+func (s *service) planPowerLimits(desired, _ interface{}) ([]*Step, error) {
+	limits, err := object(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	chassis, err := s.chassis()
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []*Step{}
+	for _, id := range utils.SortedKeys(limits) {
+		path := PowerLimit + "." + id
+		chass, ok := chassis[id]
+		if !ok {
+			if err := s.unavailable(path, fmt.Errorf("there is no chassis '%s'", id)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		resource, current, err := s.powerLimit(chass)
+		if err != nil {
+			return nil, err
+		}
+		if resource == nil {
+			if err := s.unavailable(path, fmt.Errorf("chassis '%s' does not support power limits", id)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, ok := resource["PowerControl"]; !ok {
+			steps = append(steps, update(path, resource.ID(), "PowerLimitWatts", limits[id], normalize(current))...)
+			continue
+		}
+
+		// Array members that are not changing must be sent as empty objects
+		for _, step := range update(path, resource.ID(), "", limits[id], normalize(current)) {
+			controls := []interface{}{map[string]interface{}{"PowerLimit": step.Body}}
+			for i := 1; i < len(resource["PowerControl"].([]interface{})); i++ {
+				controls = append(controls, map[string]interface{}{})
+			}
+			step.Body = map[string]interface{}{"PowerControl": controls}
+			steps = append(steps, step)
+		}
+	}
+
+	return steps, nil
+}
+
+func (s *service) planIndicatorLED(desired, current interface{}) ([]*Step, error) {
+	system, err := s.system()
+	if err != nil || current == nil {
+		return nil, errNotSupported
+	}
+
+	return update(IndicatorLED, system.ID(), "IndicatorLED", desired, current), nil
+}
+
+func (s *service) planAssetTag(desired, current interface{}) ([]*Step, error) {
+	system, err := s.system()
+	if err != nil || current == nil {
+		return nil, errNotSupported
+	}
+
+	return update(AssetTag, system.ID(), "AssetTag", desired, current), nil
+}
+
+// create gets a step that creates a member of a collection.
+func create(path, collectionURI string, body map[string]interface{}, desired interface{}) *Step {
+	return &Step{
+		Method:  http.MethodPost,
+		URI:     collectionURI,
+		Body:    body,
+		Changes: []Difference{{Path: path, Left: desired, Right: NotSet}},
+	}
+}
+
+// remove gets a step that deletes a resource.
+func remove(path, uri string, current interface{}) *Step {
+	return &Step{
+		Method:  http.MethodDelete,
+		URI:     uri,
+		Changes: []Difference{{Path: path, Left: NotSet, Right: normalize(current)}},
+	}
+}
+
+// without copies an object, leaving out the given properties.
+func without(object map[string]interface{}, properties ...string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range object {
+		if !contains(properties, key) {
+			result[key] = value
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	current := map[string]interface{}{
+		"BootOrder":                 []interface{}{"a", "b", "c"},
+		"BootSourceOverrideEnabled": "Disabled",
+		"NTP":                       map[string]interface{}{"ProtocolEnabled": true, "Port": float64(123)},
+	}
+
+	tests := []struct {
+		name     string
+		desired  map[string]interface{}
+		expected interface{}
+	}{
+		{
+			name:    "unchanged",
+			desired: map[string]interface{}{"BootSourceOverrideEnabled": "Disabled", "NTP": map[string]interface{}{"Port": float64(123)}},
+		},
+		{
+			name:     "changed property",
+			desired:  map[string]interface{}{"BootSourceOverrideEnabled": "Once", "NTP": map[string]interface{}{"ProtocolEnabled": true}},
+			expected: map[string]interface{}{"BootSourceOverrideEnabled": "Once"},
+		},
+		{
+			name:     "nested property",
+			desired:  map[string]interface{}{"NTP": map[string]interface{}{"ProtocolEnabled": false, "Port": float64(123)}},
+			expected: map[string]interface{}{"NTP": map[string]interface{}{"ProtocolEnabled": false}},
+		},
+		{
+			// PATCH updates lists by position, so extra items are removed with nulls
+			name:     "shorter list",
+			desired:  map[string]interface{}{"BootOrder": []interface{}{"b", "a"}},
+			expected: map[string]interface{}{"BootOrder": []interface{}{"b", "a", nil}},
+		},
+		{
+			name:     "new property",
+			desired:  map[string]interface{}{"BootSourceOverrideMode": "UEFI"},
+			expected: map[string]interface{}{"BootSourceOverrideMode": "UEFI"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, changed := patch(test.desired, current)
+			if changed != (test.expected != nil) || (changed && !reflect.DeepEqual(body, test.expected)) {
+				t.Errorf("expected %v, got %v (changed %v)", test.expected, body, changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,740 @@
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package settings reads the configuration of a Redfish service into a
+// document of named sections, such as bios or ntp, that can be saved and
+// compared. Values use the Redfish property names so any setting a service
+// supports can be included.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// Settings holds configuration by section name. The values are decoded JSON.
+type Settings map[string]interface{}
+
+// The sections of the settings.
+const (
+	// BIOS holds the BIOS attributes of the system.
+	BIOS = "bios"
+	// Boot holds the boot order and override of the system.
+	Boot = "boot"
+	// NetworkProtocols holds the protocols of the manager, other than NTP,
+	// with whether each is enabled and its port.
+	NetworkProtocols = "network_protocols"
+	// NTP holds whether the manager uses NTP and its servers.
+	NTP = "ntp"
+	// DNS holds the host name of the manager and the name servers it uses.
+	DNS = "dns"
+	// AccountPolicy holds the password and lockout policy of the accounts.
+	AccountPolicy = "account_policy"
+	// Users holds the role and enabled state of each account by user name.
+	Users = "users"
+	// LDAP holds the LDAP service the accounts are authenticated with,
+	// without its password.
+	LDAP = "ldap"
+	// Subscriptions holds the event subscriptions by destination.
+	Subscriptions = "subscriptions"
+	// PowerLimit holds the power limit of each chassis by ID.
+	PowerLimit = "power_limit"
+	// IndicatorLED holds the state of the indicator LED of the system.
+	IndicatorLED = "indicator_led"
+	// AssetTag holds the asset tag of the system.
+	AssetTag = "asset_tag"
+	// Firmware holds the version of each firmware component. It cannot be
+	// changed through settings.
+	Firmware = "firmware"
+)
+
+// section is a part of the settings, how to read it and how to plan changes
+// to it. Sections without a plan cannot be changed.
+type section struct {
+	name string
+	read func(*service) (interface{}, error)
+	plan planner
+}
+
+// sections are all of the sections, in the order they are read and shown.
+var sections = []section{
+	{BIOS, (*service).bios, (*service).planBIOS},
+	{Boot, (*service).boot, (*service).planBoot},
+	{NetworkProtocols, (*service).networkProtocols, (*service).planNetworkProtocols},
+	{NTP, (*service).ntp, (*service).planNTP},
+	{DNS, (*service).dns, (*service).planDNS},
+	{AccountPolicy, (*service).accountPolicy, (*service).planAccountPolicy},
+	{Users, (*service).users, (*service).planUsers},
+	{LDAP, (*service).ldap, (*service).planLDAP},
+	{Subscriptions, (*service).subscriptions, (*service).planSubscriptions},
+	{PowerLimit, (*service).powerLimits, (*service).planPowerLimits},
+	{IndicatorLED, (*service).indicatorLED, (*service).planIndicatorLED},
+	{AssetTag, (*service).assetTag, (*service).planAssetTag},
+	{Firmware, (*service).firmware, nil},
+}
+
+// Sections gets the names of all sections, in order.
+func Sections() []string {
+	names := []string{}
+	for _, s := range sections {
+		names = append(names, s.name)
+	}
+
+	return names
+}
+
+// Names gets the names of the sections in the settings, in order. Sections
+// that are not known are listed last.
+func (s Settings) Names() []string {
+	names := []string{}
+	for _, known := range Sections() {
+		if _, ok := s[known]; ok {
+			names = append(names, known)
+		}
+	}
+
+	for _, name := range utils.SortedKeys(s) {
+		if !isSection(name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func isSection(name string) bool {
+	for _, s := range sections {
+		if s.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks that the settings only have known sections.
+func (s Settings) Validate() error {
+	for name := range s {
+		if !isSection(name) {
+			return fmt.Errorf("unknown section '%s', must be one of: %s", name, strings.Join(Sections(), ", "))
+		}
+	}
+
+	return nil
+}
+
+// Read gets the current settings of a service. Only the named sections are
+// read, or all of them if none are named. Sections the service does not have
+// are left out. The settings that could be read are returned along with an
+// error for any sections that could not.
+func Read(c common.Client, names ...string) (Settings, error) {
+	svc := newService(c)
+	result := Settings{}
+	failures := &ReadError{Sections: map[string]error{}}
+
+	for _, s := range sections {
+		if len(names) > 0 && !contains(names, s.name) {
+			continue
+		}
+
+		value, err := s.read(svc)
+		if err != nil {
+			failures.Sections[s.name] = err
+			continue
+		}
+		if value != nil {
+			result[s.name] = normalize(value)
+		}
+	}
+
+	if len(failures.Sections) > 0 {
+		return result, failures
+	}
+
+	return result, nil
+}
+
+// ReadError lists the sections that could not be read.
+type ReadError struct {
+	Sections map[string]error
+}
+
+func (e *ReadError) Error() string {
+	failures := []string{}
+	for _, name := range Sections() {
+		if err, ok := e.Sections[name]; ok {
+			failures = append(failures, fmt.Sprintf("%s: %s", name, utils.ErrorMessage(err)))
+		}
+	}
+
+	return "unable to read " + strings.Join(failures, "; ")
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalize makes values comparable by converting them to decoded JSON, so
+// numbers from YAML and JSON are both float64 and maps have string keys.
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var result interface{}
+	if json.Unmarshal(data, &result) != nil {
+		return value
+	}
+
+	return result
+}
+
+// service reads the resources that hold the settings, only getting each
+// resource once.
+type service struct {
+	client common.Client
+	cache  map[string]utils.Resource
+	// skipUnavailable is set when planning should skip the settings the
+	// service cannot take, which are added to skipped.
+	skipUnavailable bool
+	skipped         []Skipped
+}
+
+func newService(c common.Client) *service {
+	return &service{client: c, cache: map[string]utils.Resource{}}
+}
+
+// get gets a resource. A missing link gives no resource and no error.
+func (s *service) get(uri string) (utils.Resource, error) {
+	if uri == "" {
+		return nil, nil
+	}
+
+	if resource, ok := s.cache[uri]; ok {
+		return resource, nil
+	}
+
+	resource, err := utils.GetResource(s.client, uri)
+	if err != nil {
+		return nil, err
+	}
+	s.cache[uri] = resource
+
+	return resource, nil
+}
+
+// first gets the first member of a collection linked from a resource.
+func (s *service) first(resource utils.Resource, property string) (utils.Resource, error) {
+	collection, err := s.get(resource.Link(property))
+	if err != nil || collection == nil {
+		return nil, err
+	}
+
+	members := collection.Members()
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	return s.get(members[0])
+}
+
+// root gets the service root.
+func (s *service) root() (utils.Resource, error) {
+	return s.get(utils.ServiceRoot)
+}
+
+// system gets the computer system, which is the first one on services with
+// more than one.
+func (s *service) system() (utils.Resource, error) {
+	root, err := s.root()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.first(root, "Systems")
+}
+
+// manager gets the manager of the system, or the first manager.
+func (s *service) manager() (utils.Resource, error) {
+	system, err := s.system()
+	if err != nil {
+		return nil, err
+	}
+
+	if system != nil {
+		links, _ := system["Links"].(map[string]interface{})
+		managers, _ := links["ManagedBy"].([]interface{})
+		if len(managers) > 0 {
+			return s.get(utils.LinkURI(managers[0]))
+		}
+	}
+
+	root, err := s.root()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.first(root, "Managers")
+}
+
+// accountService gets the account service.
+func (s *service) accountService() (utils.Resource, error) {
+	root, err := s.root()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.get(root.Link("AccountService"))
+}
+
+// networkProtocol gets the network protocol settings of the manager.
+func (s *service) networkProtocol() (utils.Resource, error) {
+	manager, err := s.manager()
+	if err != nil || manager == nil {
+		return nil, err
+	}
+
+	return s.get(manager.Link("NetworkProtocol"))
+}
+
+// managerInterface gets the first network interface of the manager.
+func (s *service) managerInterface() (utils.Resource, error) {
+	manager, err := s.manager()
+	if err != nil || manager == nil {
+		return nil, err
+	}
+
+	return s.first(manager, "EthernetInterfaces")
+}
+
+// members gets the members of a collection by the value of a property, such
+// as the accounts by user name. Members without the property are left out.
+func (s *service) members(uri, property string) (map[string]utils.Resource, error) {
+	collection, err := utils.GetAllPages(s.client, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]utils.Resource{}
+	for _, memberURI := range collection.Members() {
+		member, err := s.get(memberURI)
+		if err != nil {
+			return nil, err
+		}
+
+		if key, _ := member[property].(string); key != "" {
+			result[key] = member
+		}
+	}
+
+	return result, nil
+}
+
+// accounts gets the accounts by user name, and the URI of the collection.
+// Some services list unused account slots without a user name, which are
+// left out.
+func (s *service) accounts() (map[string]utils.Resource, string, error) {
+	accounts, err := s.accountService()
+	if err != nil || accounts == nil {
+		return nil, "", err
+	}
+
+	uri := accounts.Link("Accounts")
+	result, err := s.members(uri, "UserName")
+	return result, uri, err
+}
+
+// chassis gets the chassis by ID.
+func (s *service) chassis() (map[string]utils.Resource, error) {
+	root, err := s.root()
+	if err != nil || root.Link("Chassis") == "" {
+		return nil, err
+	}
+
+	return s.members(root.Link("Chassis"), "Id")
+}
+
+// powerLimit gets the resource holding the power limit of a chassis and the
+// limit, or no resource if the chassis does not support one.
+func (s *service) powerLimit(chass utils.Resource) (utils.Resource, interface{}, error) {
+	power, err := s.get(chass.Link("Power"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if controls, _ := power["PowerControl"].([]interface{}); len(controls) > 0 {
+		control, _ := controls[0].(map[string]interface{})
+		limit, _ := control["PowerLimit"].(map[string]interface{})
+		return power, subset(limit, "LimitInWatts", "LimitException", "CorrectionInMs"), nil
+	}
+
+	metrics, err := s.get(chass.Link("EnvironmentMetrics"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if limit, ok := metrics["PowerLimitWatts"].(map[string]interface{}); ok {
+		return metrics, subset(limit, "SetPoint", "ControlMode"), nil
+	}
+
+	return nil, nil, nil
+}
+
+// eventSubscriptions gets the event subscriptions by destination, and the
+// URI of the collection.
+func (s *service) eventSubscriptions() (map[string]utils.Resource, string, error) {
+	root, err := s.root()
+	if err != nil {
+		return nil, "", err
+	}
+
+	events, err := s.get(root.Link("EventService"))
+	if err != nil || events == nil || events.Link("Subscriptions") == "" {
+		return nil, "", err
+	}
+
+	uri := events.Link("Subscriptions")
+	result, err := s.members(uri, "Destination")
+	return result, uri, err
+}
+
+func (s *service) bios() (interface{}, error) {
+	system, err := s.system()
+	if err != nil || system == nil {
+		return nil, err
+	}
+
+	bios, err := s.get(system.Link("Bios"))
+	if err != nil || bios == nil {
+		return nil, err
+	}
+
+	return bios["Attributes"], nil
+}
+
+// readOnlyAttributes gets the names of the BIOS attributes that the attribute
+// registry of the service marks as read-only. Services that do not provide
+// the registry are taken to have none, as there is no way to tell.
+func (s *service) readOnlyAttributes(bios utils.Resource) map[string]bool {
+	result := map[string]bool{}
+	uri := s.attributeRegistry(bios)
+	if uri == "" {
+		return result
+	}
+
+	registry, err := s.get(uri)
+	if err != nil || registry == nil {
+		return result
+	}
+
+	entries, _ := registry["RegistryEntries"].(map[string]interface{})
+	attributes, _ := entries["Attributes"].([]interface{})
+	for _, value := range attributes {
+		attribute, _ := value.(map[string]interface{})
+		name, _ := attribute["AttributeName"].(string)
+		if readOnly, _ := attribute["ReadOnly"].(bool); readOnly && name != "" {
+			result[name] = true
+		}
+	}
+
+	return result
+}
+
+// attributeRegistry finds where the attribute registry of the BIOS is on the
+// service, preferring the English version.
+func (s *service) attributeRegistry(bios utils.Resource) string {
+	name, _ := bios["AttributeRegistry"].(string)
+	root, err := s.root()
+	if name == "" || err != nil {
+		return ""
+	}
+
+	registries, err := s.get(root.Link("Registries"))
+	if err != nil || registries == nil {
+		return ""
+	}
+
+	for _, member := range registries.Members() {
+		file, err := s.get(member)
+		if err != nil || (file["Id"] != name && file["Registry"] != name) {
+			continue
+		}
+
+		uri := ""
+		locations, _ := file["Location"].([]interface{})
+		for _, value := range locations {
+			location, _ := value.(map[string]interface{})
+			locationURI, _ := location["Uri"].(string)
+			language, _ := location["Language"].(string)
+			if locationURI != "" && (uri == "" || strings.HasPrefix(language, "en")) {
+				uri = locationURI
+			}
+		}
+
+		return uri
+	}
+
+	return ""
+}
+
+func (s *service) boot() (interface{}, error) {
+	system, err := s.system()
+	if err != nil || system == nil {
+		return nil, err
+	}
+
+	boot, _ := system["Boot"].(map[string]interface{})
+	return subset(boot, "BootOrder", "BootSourceOverrideEnabled", "BootSourceOverrideTarget", "BootSourceOverrideMode"), nil
+}
+
+func (s *service) networkProtocols() (interface{}, error) {
+	protocols, err := s.networkProtocol()
+	if err != nil || protocols == nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for name, value := range protocols {
+		protocol, ok := value.(map[string]interface{})
+		if _, hasEnabled := protocol["ProtocolEnabled"]; !ok || !hasEnabled || name == "NTP" {
+			continue
+		}
+		result[name] = subset(protocol, "ProtocolEnabled", "Port")
+	}
+
+	return nonEmpty(result), nil
+}
+
+func (s *service) ntp() (interface{}, error) {
+	protocols, err := s.networkProtocol()
+	if err != nil || protocols == nil {
+		return nil, err
+	}
+
+	ntp, _ := protocols["NTP"].(map[string]interface{})
+	return subset(ntp, "ProtocolEnabled", "NTPServers"), nil
+}
+
+func (s *service) dns() (interface{}, error) {
+	protocols, err := s.networkProtocol()
+	if err != nil {
+		return nil, err
+	}
+
+	nic, err := s.managerInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	if hostName, ok := protocols["HostName"]; ok {
+		result["HostName"] = hostName
+	}
+	if servers, ok := nic["StaticNameServers"]; ok {
+		result["StaticNameServers"] = servers
+	}
+
+	return nonEmpty(result), nil
+}
+
+func (s *service) accountPolicy() (interface{}, error) {
+	accounts, err := s.accountService()
+	if err != nil || accounts == nil {
+		return nil, err
+	}
+
+	return subset(accounts,
+		"MinPasswordLength", "MaxPasswordLength", "PasswordExpirationDays",
+		"AccountLockoutThreshold", "AccountLockoutDuration", "AccountLockoutCounterResetAfter",
+		"AuthFailureLoggingThreshold"), nil
+}
+
+func (s *service) users() (interface{}, error) {
+	accounts, uri, err := s.accounts()
+	if err != nil || uri == "" {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for name, account := range accounts {
+		result[name] = subset(account, userProperties...)
+	}
+
+	return result, nil
+}
+
+func (s *service) ldap() (interface{}, error) {
+	accounts, err := s.accountService()
+	if err != nil || accounts == nil {
+		return nil, err
+	}
+
+	ldap, ok := accounts["LDAP"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	result := map[string]interface{}{}
+	for _, property := range []string{"ServiceEnabled", "ServiceAddresses", "LDAPService", "RemoteRoleMapping"} {
+		if value, ok := ldap[property]; ok {
+			result[property] = value
+		}
+	}
+
+	// The password cannot be read back, so it is left out
+	authentication, _ := ldap["Authentication"].(map[string]interface{})
+	if value := subset(authentication, "AuthenticationType", "Username"); value != nil {
+		result["Authentication"] = value
+	}
+
+	return nonEmpty(result), nil
+}
+
+func (s *service) subscriptions() (interface{}, error) {
+	subscriptions, uri, err := s.eventSubscriptions()
+	if err != nil || uri == "" {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for destination, subscription := range subscriptions {
+		result[destination] = subset(subscription, subscriptionProperties...)
+	}
+
+	return result, nil
+}
+
+// powerLimits gets the power limit of each chassis that has one, from its
+// Power resource, or for newer services its EnvironmentMetrics.
+func (s *service) powerLimits() (interface{}, error) {
+	chassis, err := s.chassis()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	for id, chass := range chassis {
+		_, limit, err := s.powerLimit(chass)
+		if err != nil {
+			return nil, err
+		}
+		if limit != nil {
+			result[id] = limit
+		}
+	}
+
+	return nonEmpty(result), nil
+}
+
+func (s *service) indicatorLED() (interface{}, error) {
+	system, err := s.system()
+	if err != nil {
+		return nil, err
+	}
+
+	return system["IndicatorLED"], nil
+}
+
+func (s *service) assetTag() (interface{}, error) {
+	system, err := s.system()
+	if err != nil {
+		return nil, err
+	}
+
+	return system["AssetTag"], nil
+}
+
+// firmware gets the versions from the firmware inventory, or if there is none
+// the BIOS version and the firmware of the manager.
+func (s *service) firmware() (interface{}, error) {
+	root, err := s.root()
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	update, err := s.get(root.Link("UpdateService"))
+	if err != nil {
+		return nil, err
+	}
+	if update != nil {
+		inventory, err := s.get(update.Link("FirmwareInventory"))
+		if err != nil {
+			return nil, err
+		}
+		for _, uri := range inventory.Members() {
+			item, err := s.get(uri)
+			if err != nil {
+				return nil, err
+			}
+			id, _ := item["Id"].(string)
+			if version, ok := item["Version"].(string); ok && id != "" {
+				result[id] = version
+			}
+		}
+	}
+
+	if len(result) > 0 {
+		return result, nil
+	}
+
+	system, err := s.system()
+	if err != nil {
+		return nil, err
+	}
+	if version, ok := system["BiosVersion"].(string); ok && version != "" {
+		result["BIOS"] = version
+	}
+
+	manager, err := s.manager()
+	if err != nil {
+		return nil, err
+	}
+	id, _ := manager["Id"].(string)
+	if version, ok := manager["FirmwareVersion"].(string); ok && version != "" {
+		result[id] = version
+	}
+
+	return nonEmpty(result), nil
+}
+
+// userProperties are the properties of an account that are read.
+var userProperties = []string{"RoleId", "Enabled"}
+
+// subscriptionProperties are the properties of an event subscription that are
+// read, other than the destination.
+var subscriptionProperties = []string{
+	"Protocol", "Context", "SubscriptionType", "EventFormatType",
+	"EventTypes", "RegistryPrefixes", "ResourceTypes", "MessageIds",
+}
+
+// subset gets the properties of an object that are present, or nil if there
+// are none.
+func subset(object map[string]interface{}, properties ...string) interface{} {
+	result := map[string]interface{}{}
+	for _, property := range properties {
+		if value, ok := object[property]; ok {
+			result[property] = value
+		}
+	}
+
+	return nonEmpty(result)
+}
+
+// nonEmpty gives nil for an empty object, so the section is left out.
+func nonEmpty(object map[string]interface{}) interface{} {
+	if len(object) == 0 {
+		return nil
+	}
+
+	return object
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DebugRequests logs a line for each request with its status and timing.
+	DebugRequests = 1
+	// DebugBodies also logs the headers and bodies of requests and responses.
+	DebugBodies = 2
+)
+
+// debugLogger logs each request made through it.
+type debugLogger struct {
+	next  http.RoundTripper
+	level int
+	mu    sync.Mutex
+	out   io.Writer
+}
+
+var (
+	debugOnce   sync.Once
+	debugOut    io.Writer
+	debugOutErr error
+)
+
+// debugTransport gets the transport that logs requests at the given level,
+// either to stderr or to the log file.
+func debugTransport(level int, logFile string, next http.RoundTripper) (http.RoundTripper, error) {
+	debugOnce.Do(func() {
+		debugOut = os.Stderr
+		if logFile != "" {
+			debugOut, debugOutErr = os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		}
+	})
+	if debugOutErr != nil {
+		return nil, Error("unable to open log file: %v", debugOutErr)
+	}
+
+	return &debugLogger{next: next, level: level, out: debugOut}, nil
+}
+
+// RoundTrip sends the request and logs it along with the response.
+func (d *debugLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	log := &bytes.Buffer{}
+	fmt.Fprintf(log, "%s %s %s\n", time.Now().Format(time.RFC3339Nano), req.Method, req.URL)
+
+	if d.level >= DebugBodies {
+		writeHeaders(log, "> ", req.Header)
+		if req.Body != nil && req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				data, _ := io.ReadAll(body)
+				writeDebugBody(log, "> ", data)
+			}
+		}
+	}
+
+	start := time.Now()
+	resp, err := d.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(log, "< failed after %s: %v\n", elapsed, err)
+		d.write(log)
+		return nil, err
+	}
+
+	fmt.Fprintf(log, "< %s (%s)\n", resp.Status, elapsed)
+	if d.level >= DebugBodies {
+		writeHeaders(log, "< ", resp.Header)
+		if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			data, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(data))
+			writeDebugBody(log, "< ", data)
+		}
+	}
+
+	d.write(log)
+	return resp, nil
+}
+
+// write sends the log of one request to the output in one piece, so the logs
+// of concurrent requests do not mix.
+func (d *debugLogger) write(log *bytes.Buffer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, _ = d.out.Write(log.Bytes())
+}
+
+// writeHeaders logs headers in order, hiding credentials.
+func writeHeaders(log io.Writer, prefix string, headers http.Header) {
+	headers = redactHeaders(headers)
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(log, "%s%s: %s\n", prefix, name, strings.Join(headers[name], ", "))
+	}
+}
+
+// writeDebugBody logs a body, indenting JSON and hiding credentials in it.
+// Other content is only described, as it may be large or binary.
+func writeDebugBody(log io.Writer, prefix string, data []byte) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+
+	redacted, isJSON := redactBody(data)
+	if !isJSON {
+		fmt.Fprintf(log, "%s(%d bytes)\n", prefix, len(data))
+		return
+	}
+
+	pretty := &bytes.Buffer{}
+	_ = json.Indent(pretty, redacted, prefix, "  ")
+	fmt.Fprintf(log, "%s%s\n", prefix, pretty)
+}
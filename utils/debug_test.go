@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestDebugLog(t *testing.T) {
+	mockuptest.Start(t)
+	logFile := filepath.Join(t.TempDir(), "debug.log")
+	t.Cleanup(func() { utils.Options = utils.ClientOptions{} })
+
+	utils.Options = utils.ClientOptions{DebugLevel: utils.DebugBodies, LogFile: logFile}
+	c, err := utils.GofishClient("")
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	if _, err := c.Service.Systems(); err != nil {
+		t.Fatalf("unable to get systems: %v", err)
+	}
+	c.Logout()
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+
+	for _, expected := range []string{"GET http://", "/redfish/v1/Systems", "< 200 OK", `"Name": "web01"`, "X-Auth-Token: REDACTED"} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("expected %q in the log:\n%s", expected, log)
+		}
+	}
+
+	if strings.Contains(log, mockuptest.Password) {
+		t.Error("the log contains the password")
+	}
+}
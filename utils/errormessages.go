@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/stmcginnis/gofish"
+)
+
+// errorMessages fills in the messages missing from the error responses of a
+// service using its own registries, so the errors of every command can be
+// resolved, including those with OEM message IDs.
+type errorMessages struct {
+	next http.RoundTripper
+	// cfg is how to connect to the service to get its registries, set once
+	// the client using the transport has logged in.
+	cfg atomic.Pointer[gofish.ClientConfig]
+	// registriesOnce guards registries, which are only retrieved once an error
+	// needs them.
+	registriesOnce sync.Once
+	registries     *MessageRegistries
+}
+
+// connected sets up getting the registries using the session of a client
+// using the transport. The registries are retrieved by a client of their own
+// without this transport, as a client only makes one request at a time.
+func (e *errorMessages) connected(cfg gofish.ClientConfig, c *gofish.APIClient) {
+	session, err := c.GetSession()
+	if err != nil {
+		return
+	}
+
+	cfg.Session = session
+	cfg.HTTPClient = &http.Client{Transport: e.next}
+	e.cfg.Store(&cfg)
+}
+
+// messageRegistries gets the registries of the service, or nil if they are
+// not available.
+func (e *errorMessages) messageRegistries() *MessageRegistries {
+	cfg := e.cfg.Load()
+	if cfg == nil {
+		return nil
+	}
+
+	e.registriesOnce.Do(func() {
+		c, err := gofish.Connect(*cfg)
+		if err == nil {
+			e.registries = NewMessageRegistries(c)
+		}
+	})
+
+	return e.registries
+}
+
+func (e *errorMessages) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := e.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	registries := e.messageRegistries()
+	if registries == nil {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if resolved, ok := resolveErrorMessages(registries, body); ok {
+		body = resolved
+		resp.ContentLength = int64(len(body))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// resolveErrorMessages fills in the messages missing from a Redfish error
+// response. The boolean result is false if nothing was changed.
+func resolveErrorMessages(registries *MessageRegistries, body []byte) ([]byte, bool) {
+	document := map[string]interface{}{}
+	if json.Unmarshal(body, &document) != nil {
+		return body, false
+	}
+
+	rfErr, _ := document["error"].(map[string]interface{})
+	if rfErr == nil {
+		return body, false
+	}
+
+	changed := false
+	if code, _ := rfErr["code"].(string); code != "" && rfErr["message"] == "" {
+		if resolved, ok := registries.Resolve(code, nil); ok {
+			rfErr["message"] = resolved.Message
+			changed = true
+		}
+	}
+
+	infos, _ := rfErr["@Message.ExtendedInfo"].([]interface{})
+	for _, item := range infos {
+		info, _ := item.(map[string]interface{})
+		messageID, _ := info["MessageId"].(string)
+		if message, _ := info["Message"].(string); message != "" || messageID == "" {
+			continue
+		}
+
+		args := []string{}
+		values, _ := info["MessageArgs"].([]interface{})
+		for _, value := range values {
+			args = append(args, fmt.Sprint(value))
+		}
+
+		resolved, ok := registries.Resolve(messageID, args)
+		if !ok {
+			continue
+		}
+
+		info["Message"] = resolved.Message
+		for property, value := range map[string]string{"Severity": resolved.Severity, "Resolution": resolved.Resolution} {
+			if current, _ := info[property].(string); current == "" && value != "" {
+				info[property] = value
+			}
+		}
+		changed = true
+	}
+
+	if !changed {
+		return body, false
+	}
+
+	resolved, err := json.Marshal(document)
+	if err != nil {
+		return body, false
+	}
+
+	return resolved, true
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// Subscription describes an event subscription to create.
+type Subscription struct {
+	Destination      string
+	Context          string              `json:",omitempty"`
+	Protocol         string              `json:",omitempty"`
+	SubscriptionType string              `json:",omitempty"`
+	EventTypes       []string            `json:",omitempty"`
+	RegistryPrefixes []string            `json:",omitempty"`
+	ResourceTypes    []string            `json:",omitempty"`
+	HTTPHeaders      []map[string]string `json:"HttpHeaders,omitempty"`
+}
+
+// CreateSubscription creates an event subscription, returning its URI. The
+// requested event types, registry prefixes and resource types are checked
+// against those the service says it supports.
+//
+// The gofish helpers only allow subscribing by either event type or registry
+// prefix, so the request is built here to allow any combination.
+func CreateSubscription(eventService *redfish.EventService, subscription *Subscription) (string, error) {
+	if eventService.Subscriptions == "" {
+		return "", Error("the event service does not support subscriptions")
+	}
+
+	destination, err := url.ParseRequestURI(subscription.Destination)
+	if err != nil || !strings.HasPrefix(destination.Scheme, "http") {
+		return "", Error("destination '%s' must be an http or https URL", subscription.Destination)
+	}
+
+	supportedTypes := []string{}
+	for _, eventType := range eventService.EventTypesForSubscription {
+		supportedTypes = append(supportedTypes, string(eventType))
+	}
+
+	subscription.EventTypes, err = supportedValues("event type", subscription.EventTypes, supportedTypes)
+	if err != nil {
+		return "", err
+	}
+
+	subscription.RegistryPrefixes, err = supportedValues(
+		"registry prefix", subscription.RegistryPrefixes, eventService.RegistryPrefixes)
+	if err != nil {
+		return "", err
+	}
+
+	subscription.ResourceTypes, err = supportedValues(
+		"resource type", subscription.ResourceTypes, eventService.ResourceTypes)
+	if err != nil {
+		return "", err
+	}
+
+	if subscription.Protocol == "" {
+		subscription.Protocol = string(redfish.RedfishEventDestinationProtocol)
+	}
+
+	resp, err := eventService.GetClient().Post(eventService.Subscriptions, subscription)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	if u, err := url.Parse(location); err == nil && u.Path != "" {
+		location = u.Path
+	}
+
+	return location, nil
+}
+
+// supportedValues checks the requested values against the supported ones,
+// returning them with the capitalization the service uses. If the service does
+// not list what it supports, the values are used as given.
+func supportedValues(kind string, requested, supported []string) ([]string, error) {
+	if len(supported) == 0 {
+		return requested, nil
+	}
+
+	result := []string{}
+	for _, value := range requested {
+		found := false
+		for _, option := range supported {
+			if strings.EqualFold(value, option) {
+				result = append(result, option)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, Error("%s '%s' is not supported, must be one of: %s", kind, value, strings.Join(supported, ", "))
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"sync"
+
+	"github.com/stmcginnis/ctlfish/config"
+)
+
+// FleetWorkers is how many connections commands work on at the same time.
+const FleetWorkers = 8
+
+// Connections expands the connection and group names given to a command into
+// the connections to use, in order and without duplicates. With no names, the
+// default connection is used, named "".
+func Connections(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return []string{""}, nil
+	}
+
+	connections := []string{}
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			connections = append(connections, name)
+		}
+	}
+
+	for _, name := range names {
+		if members := config.GetGroup(name); members != nil {
+			for _, member := range members {
+				add(member)
+			}
+			continue
+		}
+
+		if config.GetSystem(name) == nil {
+			return nil, Error("connection or group '%s' was not found.", name)
+		}
+		add(name)
+	}
+
+	return connections, nil
+}
+
+// DescribeConnection names a connection from Connections in messages, where
+// the default connection is "".
+func DescribeConnection(connection string) string {
+	if connection == "" {
+		return "the default connection"
+	}
+
+	return connection
+}
+
+// ConnectionName gets the saved name of a connection from Connections, for
+// recording where something came from. The default connection is named after
+// the saved default, or "default" if there is none or a recording is being
+// replayed.
+func ConnectionName(connection string) string {
+	if connection != "" {
+		return connection
+	}
+
+	if name := config.GetDefault(); name != "" && Options.ReplayFile == "" {
+		return name
+	}
+
+	return "default"
+}
+
+// ForEachConnection calls a function for each connection, working on up to
+// FleetWorkers connections at the same time. The index of the connection is
+// passed so results can be kept in order.
+func ForEachConnection(connections []string, fn func(i int, connection string)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(FleetWorkers, len(connections)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i, connections[i])
+			}
+		}()
+	}
+
+	for i := range connections {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -2,32 +2,149 @@
 package utils
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/stmcginnis/gofish"
+	"github.com/stmcginnis/gofish/common"
 
 	"github.com/stmcginnis/ctlfish/config"
 )
 
 // ErrorExit is a helper function to format the error result of a command execution.
+// Any Redfish errors in the arguments are followed by the details of each of
+// their @Message.ExtendedInfo entries.
 func ErrorExit(cmd *cobra.Command, message string, args ...interface{}) error {
 	cmd.SilenceUsage = true
-	return Error(message, args...)
+
+	err := Error(message, args...)
+	var rfErr *common.Error
+	if errors.As(err, &rfErr) {
+		details := ErrorDetails(rfErr)
+		if details != "" {
+			return &serviceError{message: err.Error() + "\n" + details, cause: rfErr}
+		}
+	}
+
+	return err
 }
 
-// Error is a helper function to format the error results.
+// Error is a helper function to format the error results. Redfish errors in
+// the arguments are shown using their resolved message.
 func Error(message string, args ...interface{}) error {
+	// The arguments are copied so the caller's are not changed
+	args = append([]interface{}(nil), args...)
+
+	var cause *common.Error
+	for i, arg := range args {
+		err, ok := arg.(error)
+		if !ok {
+			continue
+		}
+
+		var rfErr *common.Error
+		if errors.As(err, &rfErr) {
+			args[i] = ErrorMessage(err)
+			cause = rfErr
+		}
+	}
+
 	msg := fmt.Sprintf(message, args...)
+	if cause != nil {
+		return &serviceError{message: msg, cause: cause}
+	}
+
 	return errors.New(msg)
 }
 
-// GofishClient will get a gofish client connection for the requested system.
-// If connection == "", then the default system will be retrieved.
-// The caller should close the client connection when done.
-func GofishClient(connection string) (*gofish.APIClient, error) {
-	// Create a new instance of gofish client
+// serviceError is an error message that was caused by an error returned by
+// the Redfish service.
+type serviceError struct {
+	message string
+	cause   *common.Error
+}
+
+func (e *serviceError) Error() string {
+	return e.message
+}
+
+func (e *serviceError) Unwrap() error {
+	return e.cause
+}
+
+// ErrorMessage gets the message to display for an error, preferring the
+// messages returned by the Redfish service over the raw response.
+func ErrorMessage(err error) string {
+	var rfErr *common.Error
+	if !errors.As(err, &rfErr) {
+		return err.Error()
+	}
+
+	message := serviceMessage(rfErr)
+	if message == "" {
+		return err.Error()
+	}
+
+	// Keep anything added by errors wrapping the Redfish error
+	return strings.Replace(err.Error(), rfErr.Error(), message, 1)
+}
+
+// serviceMessage gets the message returned by the service in a Redfish error,
+// or "" if there is none.
+func serviceMessage(rfErr *common.Error) string {
+	// The extended information is more specific than the general message
+	messages := []string{}
+	for _, info := range ExtendedInfo(rfErr) {
+		messages = append(messages, info.Message)
+	}
+	if len(messages) > 0 {
+		return strings.Join(messages, " ")
+	}
+
+	if rfErr.Message != "" {
+		return rfErr.Message
+	}
+
+	if resolved, ok := bundledMessages.Resolve(rfErr.Code, nil); ok {
+		return resolved.Message
+	}
+
+	return ""
+}
+
+// ErrorDetails describes each @Message.ExtendedInfo entry of a Redfish error,
+// one per line.
+func ErrorDetails(rfErr *common.Error) string {
+	lines := []string{}
+	for _, info := range ExtendedInfo(rfErr) {
+		line := "  " + info.MessageID
+		if info.Severity != "" {
+			line += fmt.Sprintf(" (%s)", info.Severity)
+		}
+		if info.Resolution != "" && info.Resolution != "None." {
+			line += ": " + info.Resolution
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Confirm asks the user to confirm an action, returning true if they agree.
+func Confirm(cmd *cobra.Command, prompt string, args ...interface{}) bool {
+	fmt.Fprintf(cmd.ErrOrStderr(), prompt+" [y/N]: ", args...)
+
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// SystemSettings gets the saved settings for the requested system. If
+// connection == "", then the default system will be retrieved.
+func SystemSettings(connection string) (*config.SystemConfig, error) {
 	var settings *config.SystemConfig
 	if connection != "" {
 		settings = config.GetSystem(connection)
@@ -39,18 +156,48 @@ func GofishClient(connection string) (*gofish.APIClient, error) {
 		return nil, Error("unable to get system connection information.\nSet default to use or provide on command line with -c [NAME].")
 	}
 
-	cfg := gofish.ClientConfig{
-		Endpoint: fmt.Sprintf("%s://%s:%d", settings.Protocol, settings.Host, settings.Port),
-		Username: settings.Username,
-		Password: settings.Password,
-		Insecure: !settings.Secure,
+	return settings, nil
+}
+
+// GofishClient will get a gofish client connection for the requested system.
+// If connection == "", then the default system will be retrieved.
+// When replaying a recording, the connection is not used.
+// The caller should close the client connection when done.
+func GofishClient(connection string) (*gofish.APIClient, error) {
+	if c := sessions.client(connection); c != nil {
+		return c, nil
+	}
+
+	// Replayed sessions are created from the recording, so any user will do
+	cfg := gofish.ClientConfig{Username: "replay"}
+	var settings *config.SystemConfig
+	if Options.ReplayFile == "" {
+		var err error
+		settings, err = SystemSettings(connection)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg = gofish.ClientConfig{
+			Endpoint: fmt.Sprintf("%s://%s:%d", settings.Protocol, settings.Host, settings.Port),
+			Username: settings.Username,
+			Password: settings.Password,
+			Insecure: !settings.Secure,
+		}
+	}
+
+	messages, err := setupHTTPClient(&cfg, settings)
+	if err != nil {
+		return nil, err
 	}
 
 	c, err := gofish.Connect(cfg)
 	if err != nil {
 		return nil, Error("failed to connect to '%s': %v", cfg.Endpoint, err)
 	}
+	messages.connected(cfg, c)
 
+	sessions.keep(connection, c)
 	return c, nil
 }
 
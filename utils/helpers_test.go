@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+
+	"github.com/stmcginnis/ctlfish/config"
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestErrorMessage(t *testing.T) {
+	rfErr := &common.Error{
+		HTTPReturnedStatusCode: 400,
+		Code:                   "Base.1.16.0.GeneralError",
+		Message:                "A general error has occurred.",
+		ExtendedInfos: []common.ErrExtendedInfo{
+			{MessageID: "Base.1.16.0.PropertyUnknown", MessageArgs: []string{"Bogus"}},
+		},
+	}
+	resolved := "The property Bogus is not in the list of valid properties for the resource."
+
+	if message := utils.ErrorMessage(rfErr); message != resolved {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	wrapped := fmt.Errorf("unable to update: %w", rfErr)
+	if message := utils.ErrorMessage(wrapped); message != "unable to update: "+resolved {
+		t.Errorf("unexpected message for wrapped error %q", message)
+	}
+
+	args := []interface{}{rfErr}
+	err := utils.Error("failed: %v", args...)
+	if err.Error() != "failed: "+resolved {
+		t.Errorf("unexpected error %q", err)
+	}
+	if args[0] != rfErr {
+		t.Error("the arguments were changed")
+	}
+}
+
+func TestServiceErrorMessages(t *testing.T) {
+	server := mockuptest.Start(t)
+
+	// The error only has the ID of a message from the OEM registry of the
+	// service
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/redfish/v1/Chassis/1/Fans/3" {
+			server.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    "Contoso.1.0.FanRemoved",
+				"message": "",
+				"@Message.ExtendedInfo": []interface{}{
+					map[string]interface{}{"MessageId": "Contoso.1.0.FanRemoved", "MessageArgs": []string{"3"}},
+				},
+			},
+		})
+	}))
+	t.Cleanup(service.Close)
+
+	endpoint, _ := url.Parse(service.URL)
+	host, port, _ := net.SplitHostPort(endpoint.Host)
+	configFile := filepath.Join(t.TempDir(), "ctlfish.yaml")
+	settings := fmt.Sprintf(
+		"default: oem\nsystems:\n  oem:\n    host: %s\n    port: %s\n    protocol: http\n    username: %s\n    password: %s\n",
+		host, port, mockuptest.Username, mockuptest.Password)
+	if err := os.WriteFile(configFile, []byte(settings), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config.InitConfig(configFile)
+
+	c, err := utils.GofishClient("")
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	defer c.Logout()
+
+	_, err = c.Get("/redfish/v1/Chassis/1/Fans/3")
+	if err == nil {
+		t.Fatal("expected the request to fail")
+	}
+
+	if message := utils.ErrorMessage(err); message != "Fan 3 was removed." {
+		t.Errorf("unexpected message %q", message)
+	}
+
+	details := utils.ErrorExit(&cobra.Command{}, "unable to get the fan: %v", err).Error()
+	if !strings.Contains(details, "Contoso.1.0.FanRemoved (Warning): Reinstall the fan.") {
+		t.Errorf("unexpected details:\n%s", details)
+	}
+}
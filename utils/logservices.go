@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stmcginnis/gofish"
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// LogServiceInfo is a log service along with the resource that provides it.
+type LogServiceInfo struct {
+	// Source describes the manager, system, or chassis the log belongs to.
+	Source  string
+	Service *redfish.LogService
+}
+
+// LogServices gets the log services of all managers, systems and chassis.
+func LogServices(service *gofish.Service) ([]LogServiceInfo, error) {
+	result := []LogServiceInfo{}
+	add := func(source string, services []*redfish.LogService) {
+		for _, logService := range services {
+			result = append(result, LogServiceInfo{Source: source, Service: logService})
+		}
+	}
+
+	managers, err := service.Managers()
+	if err != nil {
+		return nil, Error("failed to retrieve manager information: %v", err)
+	}
+	for _, manager := range managers {
+		services, err := manager.LogServices()
+		if err != nil {
+			return nil, Error("failed to get log services for manager %q: %v", manager.Name, err)
+		}
+		add(fmt.Sprintf("Manager %s", manager.ID), services)
+	}
+
+	systems, err := service.Systems()
+	if err != nil {
+		return nil, Error("failed to retrieve system information: %v", err)
+	}
+	for _, sys := range systems {
+		services, err := sys.LogServices()
+		if err != nil {
+			return nil, Error("failed to get log services for system %q: %v", sys.Name, err)
+		}
+		add(fmt.Sprintf("System %s", sys.ID), services)
+	}
+
+	chassis, err := service.Chassis()
+	if err != nil {
+		return nil, Error("failed to retrieve chassis information: %v", err)
+	}
+	for _, chass := range chassis {
+		services, err := chass.LogServices()
+		if err != nil {
+			return nil, Error("failed to get log services for chassis %q: %v", chass.Name, err)
+		}
+		add(fmt.Sprintf("Chassis %s", chass.ID), services)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Service.ODataID < result[j].Service.ODataID
+	})
+
+	return result, nil
+}
+
+// FindLogService locates a log service by its ID, name, or URI. Log service IDs
+// such as "SEL" are often repeated across managers and systems, in which case
+// the URI must be used to pick one.
+func FindLogService(service *gofish.Service, name string) (*LogServiceInfo, error) {
+	services, err := LogServices(service)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []LogServiceInfo{}
+	for _, info := range services {
+		logService := info.Service
+		if strings.TrimSuffix(logService.ODataID, "/") == strings.TrimSuffix(name, "/") {
+			return &info, nil
+		}
+
+		if logService.ID == name || logService.Name == name {
+			matches = append(matches, info)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, Error("log service '%s' was not found.", name)
+	case 1:
+		return &matches[0], nil
+	}
+
+	uris := []string{}
+	for _, info := range matches {
+		uris = append(uris, info.Service.ODataID)
+	}
+	return nil, Error("log service '%s' is ambiguous, use one of:\n  %s", name, strings.Join(uris, "\n  "))
+}
+
+// LogEntries gets all entries of a log service, oldest first. Services nearly
+// always embed the full entries in the collection, so they are decoded from it
+// directly rather than being retrieved one request at a time.
+func LogEntries(logService *redfish.LogService) ([]*redfish.LogEntry, error) {
+	c := logService.GetClient()
+
+	var links struct {
+		Entries common.Link
+	}
+	err := getJSON(c, logService.ODataID, &links)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*redfish.LogEntry{}
+	next := links.Entries.String()
+	for next != "" {
+		var page struct {
+			Members  []json.RawMessage
+			NextLink string `json:"Members@odata.nextLink"`
+		}
+		err = getJSON(c, next, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range page.Members {
+			entry := &redfish.LogEntry{}
+			err = json.Unmarshal(member, entry)
+			if err != nil {
+				return nil, err
+			}
+
+			// Only a link was provided, go get the full entry
+			if entry.ID == "" && entry.Created == "" && entry.Message == "" {
+				entry, err = redfish.GetLogEntry(c, entry.ODataID)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			entry.SetClient(c)
+			entries = append(entries, entry)
+		}
+
+		next = page.NextLink
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return EntryTime(entries[i]).Before(EntryTime(entries[j]))
+	})
+
+	return entries, nil
+}
+
+// DiagnosticDataAction describes the CollectDiagnosticData action of a log
+// service.
+type DiagnosticDataAction struct {
+	Target string `json:"target"`
+	// AllowedTypes are the supported DiagnosticDataType values. An empty list
+	// means the service did not say.
+	AllowedTypes []string `json:"DiagnosticDataType@Redfish.AllowableValues"`
+}
+
+// CollectDiagnosticDataAction gets the CollectDiagnosticData action of a log
+// service, or nil if it does not support collecting diagnostic data.
+func CollectDiagnosticDataAction(logService *redfish.LogService) (*DiagnosticDataAction, error) {
+	var actions struct {
+		Actions struct {
+			CollectDiagnosticData *DiagnosticDataAction `json:"#LogService.CollectDiagnosticData"`
+		}
+	}
+	err := getJSON(logService.GetClient(), logService.ODataID, &actions)
+	if err != nil {
+		return nil, err
+	}
+
+	action := actions.Actions.CollectDiagnosticData
+	if action == nil || action.Target == "" {
+		return nil, nil
+	}
+
+	return action, nil
+}
+
+// EntryTime gets the time a log entry was created, or the zero time if the
+// service did not provide a valid timestamp.
+func EntryTime(entry *redfish.LogEntry) time.Time {
+	timestamp := entry.Created
+	if timestamp == "" {
+		timestamp = entry.EventTimestamp
+	}
+
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// getJSON retrieves a URI and decodes the JSON response.
+func getJSON(c common.Client, uri string, result interface{}) error {
+	resp, err := c.Get(uri)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,258 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Exchange is a request and its response, as saved in a recording. Each line
+// of a recording file is one exchange.
+type Exchange struct {
+	Method         string
+	URL            string
+	RequestHeaders http.Header     `json:",omitempty"`
+	RequestBody    json.RawMessage `json:",omitempty"`
+	Status         int             `json:",omitempty"`
+	Headers        http.Header     `json:",omitempty"`
+	Body           json.RawMessage `json:",omitempty"`
+	// Text is the body of responses that are not JSON.
+	Text string `json:",omitempty"`
+	// Error is set if no response was received.
+	Error string `json:",omitempty"`
+}
+
+// secretHeaders are headers that are never saved in recordings.
+var secretHeaders = []string{"Authorization", "X-Auth-Token", "Cookie", "Set-Cookie"}
+
+// recording is a file that exchanges are saved to. All connections made by a
+// command share the same recording.
+type recording struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// recorder saves every exchange made through a connection to the recording.
+type recorder struct {
+	next      http.RoundTripper
+	recording *recording
+}
+
+var (
+	recordingsMu sync.Mutex
+	recordings   = map[string]*recording{}
+)
+
+// recordingTransport gets a transport that records the exchanges sent through
+// next to the given file.
+func recordingTransport(file string, next http.RoundTripper) (http.RoundTripper, error) {
+	recordingsMu.Lock()
+	defer recordingsMu.Unlock()
+
+	shared, found := recordings[file]
+	if !found {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, Error("unable to create recording: %v", err)
+		}
+		shared = &recording{file: f}
+		recordings[file] = shared
+	}
+
+	return &recorder{next: next, recording: shared}, nil
+}
+
+// RoundTrip sends the request and saves it along with the response.
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &Exchange{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			data, _ := io.ReadAll(body)
+			exchange.RequestBody, _ = redactBody(data)
+		}
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		exchange.Error = err.Error()
+		r.save(exchange)
+		return nil, err
+	}
+
+	exchange.Status = resp.StatusCode
+	exchange.Headers = redactHeaders(resp.Header)
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		// Streams never end, so they are passed through unrecorded
+		exchange.Text = "(event stream not recorded)"
+		r.save(exchange)
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	var isJSON bool
+	exchange.Body, isJSON = redactBody(data)
+	if !isJSON {
+		exchange.Text = string(data)
+	}
+
+	r.save(exchange)
+	return resp, nil
+}
+
+// save appends an exchange to the recording.
+func (r *recorder) save(exchange *Exchange) {
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return
+	}
+
+	r.recording.mu.Lock()
+	defer r.recording.mu.Unlock()
+	_, _ = r.recording.file.Write(append(data, '\n'))
+}
+
+// redactHeaders copies headers, hiding the values of any that hold
+// credentials.
+func redactHeaders(headers http.Header) http.Header {
+	result := headers.Clone()
+	for _, name := range secretHeaders {
+		if result.Get(name) != "" {
+			result.Set(name, Redacted)
+		}
+	}
+
+	return result
+}
+
+// redactBody hides credentials in a JSON body. The boolean result is false if
+// the body is not JSON.
+func redactBody(data []byte) (json.RawMessage, bool) {
+	var body interface{}
+	if len(bytes.TrimSpace(data)) == 0 || json.Unmarshal(data, &body) != nil {
+		return nil, false
+	}
+
+	RedactProperties(body, IsSecretProperty)
+	redacted, err := json.Marshal(body)
+	if err != nil {
+		return nil, false
+	}
+
+	return redacted, true
+}
+
+// replayer answers requests from a recording.
+type replayer struct {
+	mu        sync.Mutex
+	exchanges []*Exchange
+	used      []bool
+}
+
+// loadRecording reads a recording, returning a transport that replays it and
+// the endpoint it was recorded from.
+func loadRecording(file string) (http.RoundTripper, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, "", Error("unable to open recording: %v", err)
+	}
+	defer f.Close()
+
+	r := &replayer{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		exchange := &Exchange{}
+		err := json.Unmarshal(scanner.Bytes(), exchange)
+		if err != nil {
+			return nil, "", Error("invalid recording, line %d: %v", line, err)
+		}
+		r.exchanges = append(r.exchanges, exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", Error("unable to read recording: %v", err)
+	}
+
+	if len(r.exchanges) == 0 {
+		return nil, "", Error("the recording '%s' is empty", file)
+	}
+	r.used = make([]bool, len(r.exchanges))
+
+	endpoint, err := url.Parse(r.exchanges[0].URL)
+	if err != nil {
+		return nil, "", Error("invalid recording: %v", err)
+	}
+
+	return r, fmt.Sprintf("%s://%s", endpoint.Scheme, endpoint.Host), nil
+}
+
+// RoundTrip answers a request with the next recorded response for the same
+// method and URI. Once those are used up, the last one is repeated so polling
+// still works.
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var exchange *Exchange
+	for i, candidate := range r.exchanges {
+		recorded, err := url.Parse(candidate.URL)
+		if err != nil || candidate.Method != req.Method || recorded.RequestURI() != req.URL.RequestURI() {
+			continue
+		}
+
+		exchange = candidate
+		if !r.used[i] {
+			r.used[i] = true
+			break
+		}
+	}
+
+	if exchange == nil {
+		return nil, Error("no recorded response for %s %s", req.Method, req.URL.RequestURI())
+	}
+
+	if exchange.Error != "" {
+		return nil, Error("%s", exchange.Error)
+	}
+
+	body := []byte(exchange.Body)
+	if exchange.Text != "" {
+		body = []byte(exchange.Text)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        exchange.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
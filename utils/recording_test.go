@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stmcginnis/gofish"
+
+	"github.com/stmcginnis/ctlfish/config"
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	mockuptest.Start(t)
+	recording := filepath.Join(t.TempDir(), "recording.jsonl")
+	t.Cleanup(func() { utils.Options = utils.ClientOptions{} })
+
+	utils.Options = utils.ClientOptions{RecordFile: recording}
+	c, err := utils.GofishClient("")
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	systems, err := c.Service.Systems()
+	if err != nil || len(systems) != 1 {
+		t.Fatalf("unable to get systems: %v", err)
+	}
+	c.Logout()
+
+	data, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"`+mockuptest.Password+`"`) {
+		t.Error("the recording contains the password")
+	}
+
+	utils.Options = utils.ClientOptions{ReplayFile: recording}
+	c, err = utils.GofishClient("no-such-connection")
+	if err != nil {
+		t.Fatalf("unable to replay: %v", err)
+	}
+	defer c.Logout()
+
+	replayed, err := c.Service.Systems()
+	if err != nil || len(replayed) != 1 || replayed[0].Name != systems[0].Name {
+		t.Errorf("unexpected replayed systems: %v", err)
+	}
+
+	if _, err := c.Service.Chassis(); err == nil {
+		t.Error("expected requests that were not recorded to fail")
+	}
+}
+
+func TestRecordConnections(t *testing.T) {
+	recording := filepath.Join(t.TempDir(), "recording.jsonl")
+	t.Cleanup(func() { utils.Options = utils.ClientOptions{} })
+	utils.Options = utils.ClientOptions{RecordFile: recording}
+
+	var clients []*gofish.APIClient
+	var endpoints []string
+	for i := 0; i < 2; i++ {
+		mockuptest.Start(t)
+		system := config.GetDefaultSystem()
+		endpoints = append(endpoints, fmt.Sprintf("%s:%d", system.Host, system.Port))
+		c, err := utils.GofishClient("")
+		if err != nil {
+			t.Fatalf("unable to connect: %v", err)
+		}
+		defer c.Logout()
+		clients = append(clients, c)
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *gofish.APIClient) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if _, err := c.Service.Systems(); err != nil {
+					t.Errorf("unable to get systems: %v", err)
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, endpoint := range endpoints {
+		if !strings.Contains(string(data), endpoint) {
+			t.Errorf("the recording has no requests to %s", endpoint)
+		}
+	}
+}
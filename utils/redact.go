@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"strings"
+)
+
+// Redacted replaces the value of properties that should not be shared.
+const Redacted = "REDACTED"
+
+// secretProperties are parts of property names that hold credentials.
+var secretProperties = []string{
+	"password", "passphrase", "secret", "token", "privatekey",
+	"authenticationkey", "encryptionkey", "communitystring",
+}
+
+// serialProperties are the names of properties that identify a specific
+// piece of hardware.
+var serialProperties = []string{
+	"serialnumber", "uuid", "assettag", "serviceidentification",
+	"macaddress", "permanentmacaddress", "durablename",
+	"associatedmacaddresses", "associatednetworkaddresses",
+}
+
+// IsSecretProperty checks if a property holds credentials.
+func IsSecretProperty(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range secretProperties {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsSerialProperty checks if a property identifies a specific piece of
+// hardware.
+func IsSerialProperty(name string) bool {
+	name = strings.ToLower(name)
+	for _, serial := range serialProperties {
+		if name == serial {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactProperties replaces the values of matching properties anywhere in
+// decoded JSON. Only non-empty strings, and the non-empty strings in lists,
+// are replaced, so flags such as PasswordChangeRequired keep their type.
+func RedactProperties(value interface{}, match func(name string) bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if match(key) && redactValue(item, v, key) {
+				continue
+			}
+			RedactProperties(item, match)
+		}
+	case []interface{}:
+		for _, item := range v {
+			RedactProperties(item, match)
+		}
+	}
+}
+
+// redactValue replaces the value of a property if it is a non-empty string or
+// a list of strings, such as AssociatedMACAddresses, returning whether it was
+// replaced.
+func redactValue(value interface{}, parent map[string]interface{}, key string) bool {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return false
+		}
+		parent[key] = Redacted
+		return true
+	case []interface{}:
+		redacted := false
+		for i, item := range v {
+			if text, ok := item.(string); ok && text != "" {
+				v[i] = Redacted
+				redacted = true
+			}
+		}
+		return redacted
+	}
+
+	return false
+}
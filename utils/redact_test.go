@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+func TestRedactProperties(t *testing.T) {
+	port := map[string]interface{}{
+		"SerialNumber":               "SN123",
+		"PartNumber":                 "PN456",
+		"AssociatedNetworkAddresses": []interface{}{"12:44:6A:3B:04:11", ""},
+		"Ethernet": map[string]interface{}{
+			"AssociatedMACAddresses": []interface{}{"12:44:6A:3B:04:11"},
+		},
+		"UUID": "",
+	}
+
+	utils.RedactProperties(port, utils.IsSerialProperty)
+
+	expected := map[string]interface{}{
+		"SerialNumber":               utils.Redacted,
+		"PartNumber":                 "PN456",
+		"AssociatedNetworkAddresses": []interface{}{utils.Redacted, ""},
+		"Ethernet": map[string]interface{}{
+			"AssociatedMACAddresses": []interface{}{utils.Redacted},
+		},
+		"UUID": "",
+	}
+	if !reflect.DeepEqual(port, expected) {
+		t.Errorf("unexpected redacted properties: %v", port)
+	}
+}
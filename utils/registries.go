@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/stmcginnis/gofish"
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// The bundled registries are copies of the DMTF Base, TaskEvent and
+// ResourceEvent registries, which are described in registries/README.md. They
+// are used when the service does not provide its own registries.
+//
+//go:embed registries/*.json
+var bundledRegistryFiles embed.FS
+
+// ResolvedMessage is a message with its arguments substituted and the details
+// from its registry.
+type ResolvedMessage struct {
+	MessageID  string
+	Message    string
+	Severity   string
+	Resolution string
+}
+
+// MessageRegistries resolves message IDs using the registries of a service,
+// falling back to the bundled DMTF registries. Each connection needs its own,
+// as services may provide different versions of the registries.
+type MessageRegistries struct {
+	client common.Client
+	// filesOnce guards loading files, which maps a registry prefix and major
+	// version, such as "Base.1", to the registry file on the service.
+	filesOnce sync.Once
+	files     map[string]*redfish.MessageRegistryFile
+	// loaded holds the registries retrieved so far, nil if unavailable.
+	mu     sync.Mutex
+	loaded map[string]*redfish.MessageRegistry
+}
+
+// bundledMessages resolves messages using only the bundled registries, for
+// when there is no connection to get the registries of.
+var bundledMessages = NewMessageRegistries(nil)
+
+var (
+	bundledOnce       sync.Once
+	bundledRegistries map[string]*redfish.MessageRegistry
+)
+
+// NewMessageRegistries creates a registry lookup for the service a client is
+// connected to. If c is nil, only the bundled registries are used.
+func NewMessageRegistries(c common.Client) *MessageRegistries {
+	return &MessageRegistries{
+		client: c,
+		loaded: map[string]*redfish.MessageRegistry{},
+	}
+}
+
+// Resolve looks up a message ID and fills in its arguments. The boolean result
+// is false if the message is not found in any registry.
+func (r *MessageRegistries) Resolve(messageID string, args []string) (ResolvedMessage, bool) {
+	result := ResolvedMessage{MessageID: messageID}
+
+	key, name, ok := splitMessageID(messageID)
+	if !ok {
+		return result, false
+	}
+
+	registry := r.registry(key)
+	if registry == nil {
+		return result, false
+	}
+
+	message, ok := registry.Messages[name]
+	if !ok {
+		return result, false
+	}
+
+	result.Message = FormatMessage(message.Message, args)
+	result.Severity = message.MessageSeverity
+	if result.Severity == "" {
+		result.Severity = message.Severity
+	}
+	result.Resolution = message.Resolution
+	return result, true
+}
+
+// Message gets the text for a message, using the text provided by the service
+// if there is one, otherwise resolving it from the registries.
+func (r *MessageRegistries) Message(messageID, message string, args []string) string {
+	if message != "" {
+		return message
+	}
+
+	resolved, ok := r.Resolve(messageID, args)
+	if !ok {
+		return messageID
+	}
+
+	return resolved.Message
+}
+
+// registry gets the registry for a prefix and major version, preferring the
+// one provided by the service.
+func (r *MessageRegistries) registry(key string) *redfish.MessageRegistry {
+	r.mu.Lock()
+	registry, found := r.loaded[key]
+	r.mu.Unlock()
+
+	if !found {
+		// Registries are retrieved without holding the lock, so a slow
+		// service does not hold up messages from registries already loaded
+		registry = r.serviceRegistry(key)
+
+		r.mu.Lock()
+		r.loaded[key] = registry
+		r.mu.Unlock()
+	}
+
+	if registry != nil {
+		return registry
+	}
+
+	bundledOnce.Do(loadBundledRegistries)
+	return bundledRegistries[key]
+}
+
+// serviceRegistry retrieves a registry from the service. Any problems are
+// ignored so the bundled registries can be used instead.
+func (r *MessageRegistries) serviceRegistry(key string) *redfish.MessageRegistry {
+	if r.client == nil {
+		return nil
+	}
+
+	r.filesOnce.Do(r.loadFiles)
+	file := r.files[key]
+	if file == nil {
+		return nil
+	}
+
+	// Only English is guaranteed, so use it if available
+	uri := ""
+	for _, location := range file.Location {
+		if location.URI == "" {
+			continue
+		}
+
+		if uri == "" || strings.HasPrefix(location.Language, "en") {
+			uri = location.URI
+		}
+	}
+
+	if uri == "" {
+		return nil
+	}
+
+	registry, err := redfish.GetMessageRegistry(r.client, uri)
+	if err != nil {
+		return nil
+	}
+
+	return registry
+}
+
+// loadFiles gets the registry files the service provides.
+func (r *MessageRegistries) loadFiles() {
+	r.files = map[string]*redfish.MessageRegistryFile{}
+
+	service, err := gofish.ServiceRoot(r.client)
+	if err != nil {
+		return
+	}
+
+	files, err := service.Registries()
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		prefix, major, _ := strings.Cut(file.Registry, ".")
+		major, _, _ = strings.Cut(major, ".")
+		r.files[prefix+"."+major] = file
+	}
+}
+
+// loadBundledRegistries reads the registries embedded in the binary.
+func loadBundledRegistries() {
+	bundledRegistries = map[string]*redfish.MessageRegistry{}
+
+	entries, _ := bundledRegistryFiles.ReadDir("registries")
+	for _, entry := range entries {
+		data, err := bundledRegistryFiles.ReadFile("registries/" + entry.Name())
+		if err != nil {
+			continue
+		}
+
+		registry := &redfish.MessageRegistry{}
+		if json.Unmarshal(data, registry) != nil {
+			continue
+		}
+
+		major, _, _ := strings.Cut(registry.RegistryVersion, ".")
+		bundledRegistries[registry.RegistryPrefix+"."+major] = registry
+	}
+}
+
+// splitMessageID splits a message ID such as "Base.1.8.Success" into the
+// registry prefix with its major version ("Base.1") and the message name. The
+// minor versions of a registry only add messages, so any may be used.
+func splitMessageID(messageID string) (key, name string, ok bool) {
+	parts := strings.Split(messageID, ".")
+	if len(parts) < 3 {
+		return "", "", false
+	}
+
+	return parts[0] + "." + parts[1], parts[len(parts)-1], true
+}
+
+// FormatMessage substitutes the %1, %2, ... placeholders of a registry message
+// with the message arguments.
+func FormatMessage(message string, args []string) string {
+	// Replace the highest numbers first so %1 does not match part of %10
+	for i := len(args); i > 0; i-- {
+		message = strings.ReplaceAll(message, fmt.Sprintf("%%%d", i), args[i-1])
+	}
+
+	return message
+}
+
+// ExtendedInfo resolves the @Message.ExtendedInfo entries of an error. The
+// messages missing from the errors of a connection are filled in from the
+// registries of its service as the response is received, so the bundled
+// registries are only used for anything still missing.
+func ExtendedInfo(rfErr *common.Error) []ResolvedMessage {
+	result := []ResolvedMessage{}
+	for _, info := range rfErr.ExtendedInfos {
+		resolved, _ := bundledMessages.Resolve(info.MessageID, info.MessageArgs)
+		if info.Message != "" {
+			resolved.Message = info.Message
+		}
+		if info.Severity != "" {
+			resolved.Severity = info.Severity
+		}
+		if info.Resolution != "" {
+			resolved.Resolution = info.Resolution
+		}
+		if resolved.Message == "" {
+			resolved.Message = info.MessageID
+		}
+
+		result = append(result, resolved)
+	}
+
+	return result
+}
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stmcginnis/gofish/common"
+)
+
+// ServiceRoot is the URI of the Redfish service root.
+const ServiceRoot = "/redfish/v1"
+
+// Resource is the decoded JSON of any Redfish resource.
+type Resource map[string]interface{}
+
+// ResourceLink is a link to another resource found in a resource's properties.
+type ResourceLink struct {
+	// Property is the path to the link within the resource, such as
+	// Members/0 or Links/Chassis/0.
+	Property string
+	URI      string
+}
+
+// ResourceURI turns full URLs and paths relative to the service root into
+// paths the client can request.
+func ResourceURI(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme != "" {
+		uri = u.RequestURI()
+	}
+
+	if !strings.HasPrefix(uri, "/") {
+		uri = ServiceRoot + "/" + uri
+	}
+
+	return uri
+}
+
+// GetResource gets the resource at a URI.
+func GetResource(c common.Client, uri string) (Resource, error) {
+	resource := Resource{}
+	err := getJSON(c, uri, &resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// GetAllPages gets a resource, adding the members of any following pages of a
+// collection to it.
+func GetAllPages(c common.Client, uri string) (Resource, error) {
+	resource, err := GetResource(c, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	members, _ := resource["Members"].([]interface{})
+	next, _ := resource["Members@odata.nextLink"].(string)
+	for next != "" {
+		page, err := GetResource(c, next)
+		if err != nil {
+			return nil, err
+		}
+
+		more, _ := page["Members"].([]interface{})
+		members = append(members, more...)
+		next, _ = page["Members@odata.nextLink"].(string)
+	}
+
+	if _, paged := resource["Members@odata.nextLink"]; paged {
+		delete(resource, "Members@odata.nextLink")
+		resource["Members"] = members
+		resource["Members@odata.count"] = len(members)
+	}
+
+	return resource, nil
+}
+
+// ID gets the @odata.id of the resource.
+func (r Resource) ID() string {
+	id, _ := r["@odata.id"].(string)
+	return id
+}
+
+// Link gets the URI of the link in a property, or "" if it is not a link.
+func (r Resource) Link(property string) string {
+	return LinkURI(r[property])
+}
+
+// Members gets the URIs of the members of a collection.
+func (r Resource) Members() []string {
+	uris := []string{}
+	members, _ := r["Members"].([]interface{})
+	for _, member := range members {
+		if uri := LinkURI(member); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+
+	return uris
+}
+
+// LinkURI gets the URI of a link object, or "" if the value is not a link.
+func LinkURI(value interface{}) string {
+	link, _ := value.(map[string]interface{})
+	uri, _ := link["@odata.id"].(string)
+	return uri
+}
+
+// Links gets all links to other resources, in property order. Links to
+// properties within the resource itself are left out.
+func (r Resource) Links() []ResourceLink {
+	self := strings.TrimSuffix(r.ID(), "/")
+	links := []ResourceLink{}
+	seen := map[string]bool{}
+
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for _, key := range SortedKeys(v) {
+				uri, isString := v[key].(string)
+				switch {
+				case key == "@odata.id" && isString:
+					// A link object, the property is its parent
+					addLink(&links, seen, self, path, uri)
+				case strings.HasSuffix(key, "@odata.nextLink") && isString:
+					addLink(&links, seen, self, joinPath(path, key), uri)
+				default:
+					walk(joinPath(path, key), v[key])
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(joinPath(path, strconv.Itoa(i)), item)
+			}
+		}
+	}
+
+	for _, key := range SortedKeys(r) {
+		if key != "@odata.id" {
+			walk(key, r[key])
+		}
+	}
+
+	return links
+}
+
+// Contained checks if the link is to a resource contained below the parent
+// resource, rather than a reference to a resource elsewhere in the tree.
+func (l ResourceLink) Contained(parent string) bool {
+	if strings.HasPrefix(l.Property, "Links/") {
+		return false
+	}
+
+	return strings.HasPrefix(l.URI, strings.TrimSuffix(parent, "/")+"/")
+}
+
+// addLink records a link unless it points back into the resource itself or was
+// already found.
+func addLink(links *[]ResourceLink, seen map[string]bool, self, property, uri string) {
+	uri, _, _ = strings.Cut(uri, "#")
+	uri = strings.TrimSuffix(uri, "/")
+	if uri == "" || uri == self || seen[uri] {
+		return
+	}
+
+	seen[uri] = true
+	*links = append(*links, ResourceLink{Property: property, URI: uri})
+}
+
+// joinPath adds a key to a property path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "/" + key
+}
+
+// SortedKeys gets the keys of a decoded JSON object in order.
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
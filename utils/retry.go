@@ -0,0 +1,305 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/stmcginnis/ctlfish/config"
+)
+
+const (
+	// DefaultTimeout is how long a request may take if not configured.
+	DefaultTimeout = 2 * time.Minute
+	// DefaultConnectTimeout is how long connecting may take if not configured.
+	DefaultConnectTimeout = 30 * time.Second
+	// DefaultRetries is how many times failed requests are retried if not
+	// configured.
+	DefaultRetries = 2
+
+	// maxBackoff is the longest wait between retries, unless the service asks
+	// for longer with Retry-After.
+	maxBackoff = 30 * time.Second
+	// maxRetryAfter is the longest Retry-After that is waited for.
+	maxRetryAfter = 2 * time.Minute
+)
+
+// retryBackoff is the wait before the first retry, doubled for each retry
+// after that.
+var retryBackoff = time.Second
+
+// connectionLimits are the timeouts, retries and rate limit of a connection.
+type connectionLimits struct {
+	timeout        time.Duration
+	connectTimeout time.Duration
+	retries        int
+	rateLimit      float64
+}
+
+// limitsFor gets the limits for a connection. The global flags take
+// precedence over the connection settings, which take precedence over the
+// defaults. The settings may be nil if there are none.
+func limitsFor(settings *config.SystemConfig) connectionLimits {
+	limits := connectionLimits{
+		timeout:        DefaultTimeout,
+		connectTimeout: DefaultConnectTimeout,
+		retries:        DefaultRetries,
+	}
+
+	if settings != nil {
+		if settings.Timeout > 0 {
+			limits.timeout = settings.Timeout
+		}
+		if settings.ConnectTimeout > 0 {
+			limits.connectTimeout = settings.ConnectTimeout
+		}
+		if settings.Retries != nil {
+			limits.retries = *settings.Retries
+		}
+		limits.rateLimit = settings.RateLimit
+	}
+
+	if Options.Timeout > 0 {
+		limits.timeout = Options.Timeout
+	}
+	if Options.ConnectTimeout > 0 {
+		limits.connectTimeout = Options.ConnectTimeout
+	}
+	if Options.Retries != nil {
+		limits.retries = *Options.Retries
+	}
+	if Options.RateLimit > 0 {
+		limits.rateLimit = Options.RateLimit
+	}
+
+	return limits
+}
+
+// retrier applies the timeout, retries and rate limit of a connection to each
+// request made through it.
+type retrier struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	retries int
+	limiter *rateLimiter
+}
+
+func retryTransport(limits connectionLimits, next http.RoundTripper) http.RoundTripper {
+	return &retrier{
+		next:    next,
+		timeout: limits.timeout,
+		retries: limits.retries,
+		limiter: newRateLimiter(limits.rateLimit),
+	}
+}
+
+// RoundTrip sends a request, retrying it with increasing waits while it fails
+// in a way that is worth retrying.
+func (r *retrier) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		err := r.limiter.wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				attemptReq.Body, err = req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		resp, err := r.send(attemptReq)
+		if attempt >= r.retries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := backoff(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		err = sleep(ctx, delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send makes one attempt of a request, cancelling it if it takes longer than
+// the timeout. Event streams are only limited until the response starts, as
+// they are expected to stay open.
+func (r *retrier) send(req *http.Request) (*http.Response, error) {
+	if r.timeout <= 0 {
+		return r.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(r.timeout, cancel)
+
+	resp, err := r.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		if !timer.Stop() {
+			err = Error("%s %s timed out after %s", req.Method, req.URL.RequestURI(), r.timeout)
+		}
+		cancel()
+		return nil, err
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		timer.Stop()
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: func() {
+		timer.Stop()
+		cancel()
+	}}
+	return resp, nil
+}
+
+// cancelOnClose releases the timeout of a request once its response has been
+// read.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// shouldRetry checks if a request failed in a way that may succeed if tried
+// again. Requests that may change something are only retried when the service
+// could not have acted on them.
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be sent again
+		return false
+	}
+
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return true
+		}
+		return isIdempotent(req.Method) &&
+			(errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(req.Method)
+	}
+
+	return false
+}
+
+// isIdempotent checks if sending a request more than once has the same effect
+// as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// backoff gets how long to wait before the next retry. The Retry-After header
+// is used if the service sent one.
+func backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(delay, maxRetryAfter)
+		}
+	}
+
+	return min(retryBackoff<<attempt, maxBackoff)
+}
+
+// retryAfter parses a Retry-After header, which is either a number of seconds
+// or a date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
+
+// sleep waits for the given time, returning early if the context ends.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateLimiter spaces out requests so no more than a set number are started
+// each second. A nil limiter does not limit anything.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request may be started.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	start := time.Now()
+	if l.next.After(start) {
+		start = l.next
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(start))
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first requests with the given status before
+// succeeding, counting the requests it gets.
+func flakyServer(t *testing.T, failures int32, status int, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRetries(t *testing.T) {
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = time.Second })
+
+	tests := []struct {
+		method   string
+		status   int
+		retries  int
+		expected int
+		calls    int32
+	}{
+		{http.MethodGet, http.StatusServiceUnavailable, 2, http.StatusOK, 3},
+		{http.MethodGet, http.StatusServiceUnavailable, 1, http.StatusServiceUnavailable, 2},
+		{http.MethodGet, http.StatusInternalServerError, 2, http.StatusOK, 3},
+		{http.MethodPost, http.StatusServiceUnavailable, 2, http.StatusOK, 3},
+		{http.MethodPost, http.StatusInternalServerError, 2, http.StatusInternalServerError, 1},
+		{http.MethodGet, http.StatusNotFound, 2, http.StatusNotFound, 1},
+	}
+
+	for _, test := range tests {
+		calls := &atomic.Int32{}
+		server := flakyServer(t, 2, test.status, calls)
+		client := &http.Client{Transport: retryTransport(connectionLimits{retries: test.retries}, http.DefaultTransport)}
+
+		req, _ := http.NewRequest(test.method, server.URL, strings.NewReader("{}"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s with %d failed: %v", test.method, test.status, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != test.expected || calls.Load() != test.calls {
+			t.Errorf("%s with %d and %d retries: expected %d after %d calls, got %d after %d",
+				test.method, test.status, test.retries, test.expected, test.calls, resp.StatusCode, calls.Load())
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: retryTransport(connectionLimits{timeout: 50 * time.Millisecond}, http.DefaultTransport)}
+	_, err := client.Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	calls := &atomic.Int32{}
+	server := flakyServer(t, 0, http.StatusOK, calls)
+	client := &http.Client{Transport: retryTransport(connectionLimits{rateLimit: 20}, http.DefaultTransport)}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 requests at 20 per second took only %s", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if delay, ok := retryAfter("3"); !ok || delay != 3*time.Second {
+		t.Errorf("unexpected delay for seconds: %s", delay)
+	}
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if delay, ok := retryAfter(date); !ok || delay <= 0 || delay > time.Minute {
+		t.Errorf("unexpected delay for date: %s", delay)
+	}
+
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("expected an invalid value to be ignored")
+	}
+}
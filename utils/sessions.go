@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/stmcginnis/gofish"
+)
+
+// sessionCache holds the clients that are kept logged in while a command runs
+// repeatedly, so each run does not have to create a new session.
+type sessionCache struct {
+	mu      sync.Mutex
+	active  bool
+	clients map[string]*gofish.APIClient
+	// kept holds the paths of the sessions that logging out should leave alone,
+	// with the connection they are for.
+	kept map[string]string
+}
+
+var sessions = &sessionCache{}
+
+// KeepSessions makes GofishClient reuse the same logged in client for each
+// connection, with Logout leaving the session open. The returned function
+// stops reusing them and logs them out.
+func KeepSessions() func() {
+	sessions.mu.Lock()
+	sessions.active = true
+	sessions.clients = map[string]*gofish.APIClient{}
+	sessions.kept = map[string]string{}
+	sessions.mu.Unlock()
+
+	return func() {
+		sessions.mu.Lock()
+		clients := sessions.clients
+		sessions.active = false
+		sessions.clients = nil
+		sessions.kept = nil
+		sessions.mu.Unlock()
+
+		for _, c := range clients {
+			c.Logout()
+		}
+	}
+}
+
+// ForgetSession logs out the kept client of a connection, if there is one, so
+// the next use logs in again. This is used when the session may have expired.
+func ForgetSession(connection string) {
+	sessions.mu.Lock()
+	c := sessions.clients[connection]
+	delete(sessions.clients, connection)
+	for path, kept := range sessions.kept {
+		if kept == connection {
+			delete(sessions.kept, path)
+		}
+	}
+	sessions.mu.Unlock()
+
+	if c != nil {
+		c.Logout()
+	}
+}
+
+// client gets the kept client of a connection, if there is one.
+func (s *sessionCache) client(connection string) *gofish.APIClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.clients[connection]
+}
+
+// keep holds on to a client if sessions are being kept.
+func (s *sessionCache) keep(connection string, c *gofish.APIClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A client created while another was being kept for the connection, such
+	// as by concurrent uses, is logged out as usual
+	if !s.active || s.clients[connection] != nil {
+		return
+	}
+
+	session, err := c.GetSession()
+	if err != nil {
+		return
+	}
+
+	s.clients[connection] = c
+	s.kept[sessionPath(session.ID)] = connection
+}
+
+// isKept checks if a session should be left open.
+func (s *sessionCache) isKept(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, kept := s.kept[path]
+	return kept
+}
+
+// sessionPath gets the path of a session from its URI, which services may give
+// as a full URL.
+func sessionPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	return u.Path
+}
+
+// sessionKeeper skips deleting the sessions that are being kept, so Logout
+// leaves them open.
+type sessionKeeper struct {
+	next http.RoundTripper
+}
+
+func (k *sessionKeeper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodDelete && sessions.isKept(req.URL.Path) {
+		return &http.Response{
+			Status:     "204 No Content",
+			StatusCode: http.StatusNoContent,
+			Proto:      req.Proto,
+			ProtoMajor: req.ProtoMajor,
+			ProtoMinor: req.ProtoMinor,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+
+	return k.next.RoundTrip(req)
+}
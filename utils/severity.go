@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// Severity indicates how concerning a reading or health status is.
+type Severity int
+
+const (
+	// SeverityOK indicates normal operation.
+	SeverityOK Severity = iota
+	// SeverityWarning indicates a condition that needs attention.
+	SeverityWarning
+	// SeverityCritical indicates a condition that needs immediate attention.
+	SeverityCritical
+)
+
+// String returns the Redfish health name for the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return string(common.WarningHealth)
+	case SeverityCritical:
+		return string(common.CriticalHealth)
+	default:
+		return string(common.OKHealth)
+	}
+}
+
+// MaxSeverity returns the most severe of the given values.
+func MaxSeverity(severities ...Severity) Severity {
+	result := SeverityOK
+	for _, severity := range severities {
+		if severity > result {
+			result = severity
+		}
+	}
+
+	return result
+}
+
+// HealthSeverity maps a Redfish health value to a severity. Missing health
+// information is treated as OK.
+func HealthSeverity(health common.Health) Severity {
+	switch health {
+	case common.WarningHealth:
+		return SeverityWarning
+	case common.CriticalHealth:
+		return SeverityCritical
+	default:
+		return SeverityOK
+	}
+}
+
+// ThresholdSeverity evaluates a reading against its lower and upper caution and
+// critical thresholds. Services omit thresholds they do not support, so zero
+// values are treated as not set.
+func ThresholdSeverity(reading, lowerCritical, lowerCaution, upperCaution, upperCritical float32) Severity {
+	switch {
+	case upperCritical != 0 && reading >= upperCritical,
+		lowerCritical != 0 && reading <= lowerCritical:
+		return SeverityCritical
+	case upperCaution != 0 && reading >= upperCaution,
+		lowerCaution != 0 && reading <= lowerCaution:
+		return SeverityWarning
+	default:
+		return SeverityOK
+	}
+}
+
+// SensorSeverity evaluates a sensor's health and reading against its thresholds,
+// returning the most severe result.
+func SensorSeverity(sensor *redfish.Sensor) Severity {
+	thresholds := sensor.Thresholds
+	return MaxSeverity(
+		HealthSeverity(sensor.Status.Health),
+		ThresholdSeverity(
+			sensor.Reading,
+			thresholds.LowerCritical.Reading,
+			thresholds.LowerCaution.Reading,
+			thresholds.UpperCaution.Reading,
+			thresholds.UpperCritical.Reading))
+}
+
+// ExitError is returned by commands that need to exit with a specific code,
+// such as health checks reporting their result.
+type ExitError struct {
+	Code    int
+	Message string
+}
+
+func (e *ExitError) Error() string {
+	return e.Message
+}
+
+// ExitUnknown is the exit code of monitoring commands that fail before they
+// can report a severity, so the failure is not taken as a Warning.
+const ExitUnknown = 3
+
+// monitoringAnnotation marks commands that report a severity in their exit
+// code.
+const monitoringAnnotation = "monitoring"
+
+// MarkMonitoring marks a command as reporting a severity in its exit code, so
+// any failure exits with ExitUnknown.
+func MarkMonitoring(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[monitoringAnnotation] = "true"
+}
+
+// ExitCode gets the code to exit with after a command returned an error.
+func ExitCode(cmd *cobra.Command, err error) int {
+	var exitErr *ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case cmd != nil && cmd.Annotations[monitoringAnnotation] != "":
+		return ExitUnknown
+	default:
+		return 1
+	}
+}
+
+// SeverityExit returns an error that exits with a monitoring plugin style
+// code (0 OK, 1 Warning, 2 Critical) for the given severity, or nil if OK.
+func SeverityExit(cmd *cobra.Command, severity Severity) error {
+	if severity == SeverityOK {
+		return nil
+	}
+
+	// The output has already been shown, so there is nothing more to print
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &ExitError{Code: int(severity), Message: severity.String()}
+}
@@ -4,6 +4,7 @@ package utils
 import (
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/olekukonko/tablewriter"
@@ -14,6 +15,7 @@ const colWidth = 50
 type TableOutputWriter interface {
 	SetHeaders(headers ...string)
 	AddRow(items ...interface{})
+	AddHighlightedRow(severity Severity, items ...interface{})
 	Render()
 	RowCount() int
 }
@@ -30,6 +32,11 @@ func convertToUpper(headers []string) []string {
 
 // NewTableWriter gets a new instance of our table output writer.
 func NewTableWriter(output io.Writer, headers ...string) TableOutputWriter {
+	// Tables are collected rather than printed while a command is watched
+	if captured := capturedTableWriter(output, headers); captured != nil {
+		return captured
+	}
+
 	// Initialize the output writer that we use under the covers
 	table := tablewriter.NewWriter(output)
 	table.SetBorder(false)
@@ -46,6 +53,7 @@ func NewTableWriter(output io.Writer, headers ...string) TableOutputWriter {
 	t := &tableoutputwriter{}
 	t.out = output
 	t.table = table
+	t.color = isTerminal(output)
 
 	return t
 }
@@ -54,6 +62,27 @@ func NewTableWriter(output io.Writer, headers ...string) TableOutputWriter {
 type tableoutputwriter struct {
 	out   io.Writer
 	table *tablewriter.Table
+	color bool
+}
+
+// isTerminal checks if output is going to an interactive terminal, so we only
+// emit color codes when someone is there to see them.
+func isTerminal(output io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := output.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
 func (t *tableoutputwriter) SetHeaders(headers ...string) {
@@ -63,13 +92,38 @@ func (t *tableoutputwriter) SetHeaders(headers ...string) {
 
 // AddRow appends a new row to our table.
 func (t *tableoutputwriter) AddRow(items ...interface{}) {
-	row := []string{}
+	t.table.Append(toStrings(items))
+}
+
+// AddHighlightedRow appends a new row to our table, colored to call attention
+// to it if the severity is not OK.
+func (t *tableoutputwriter) AddHighlightedRow(severity Severity, items ...interface{}) {
+	row := toStrings(items)
+	if !t.color || severity == SeverityOK {
+		t.table.Append(row)
+		return
+	}
+
+	color := tablewriter.Colors{tablewriter.FgYellowColor}
+	if severity == SeverityCritical {
+		color = tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor}
+	}
 
-	// Make sure all values are ultimately strings
+	colors := []tablewriter.Colors{}
+	for range row {
+		colors = append(colors, color)
+	}
+	t.table.Rich(row, colors)
+}
+
+// toStrings makes sure all values are ultimately strings.
+func toStrings(items []interface{}) []string {
+	row := []string{}
 	for _, item := range items {
 		row = append(row, fmt.Sprintf("%v", item))
 	}
-	t.table.Append(row)
+
+	return row
 }
 
 // RowCount gets the number of rows in the table.
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// TaskStatus is the state of a task as reported by the service. The gofish
+// Task type is not used because services return CreatedResources as links,
+// which it is unable to decode.
+type TaskStatus struct {
+	ODataID         string `json:"@odata.id"`
+	TaskMonitor     string
+	TaskState       redfish.TaskState
+	TaskStatus      common.Health
+	PercentComplete int
+	Messages        []common.Message
+	Links           struct {
+		CreatedResources common.Links
+	}
+}
+
+// Running checks if the task has not reached a final state yet.
+func (t *TaskStatus) Running() bool {
+	switch t.TaskState {
+	case redfish.CompletedTaskState, redfish.ExceptionTaskState,
+		redfish.KilledTaskState, redfish.CancelledTaskState:
+		return false
+	default:
+		return t.TaskState != ""
+	}
+}
+
+// Failed checks if the task ended without completing successfully.
+func (t *TaskStatus) Failed() bool {
+	switch t.TaskState {
+	case redfish.ExceptionTaskState, redfish.KilledTaskState, redfish.CancelledTaskState:
+		return true
+	default:
+		return false
+	}
+}
+
+// TaskResult is the final response of a long running operation.
+type TaskResult struct {
+	// Location is the resource created by the operation, if any.
+	Location string
+	// Task is the last state of the task, if the service provided one.
+	Task *TaskStatus
+	// Body is the final response body.
+	Body []byte
+}
+
+// WaitForTask follows the response of an action that may be processed
+// asynchronously. If the service accepted the request as a task, its monitor is
+// polled until the operation finishes. The progress function, if provided, is
+// called with each task update.
+func WaitForTask(ctx context.Context, c common.Client, resp *http.Response, interval time.Duration, progress func(*TaskStatus)) (*TaskResult, error) {
+	result, err := readTaskResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	monitor := ""
+	if resp.StatusCode == http.StatusAccepted {
+		monitor = result.Location
+		if result.Task != nil && result.Task.TaskMonitor != "" {
+			monitor = result.Task.TaskMonitor
+		} else if monitor == "" && result.Task != nil {
+			monitor = result.Task.ODataID
+		}
+	}
+
+	for monitor != "" && taskPending(resp, result) {
+		if result.Task != nil && progress != nil {
+			progress(result.Task)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		resp, err = c.Get(monitor)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err = readTaskResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Task != nil {
+		if progress != nil {
+			progress(result.Task)
+		}
+
+		if result.Task.Failed() {
+			return result, Error("task ended in state %s: %s", result.Task.TaskState, taskMessages(c, result.Task))
+		}
+
+		if result.Location == "" && len(result.Task.Links.CreatedResources) > 0 {
+			result.Location = result.Task.Links.CreatedResources[0].String()
+		}
+	}
+
+	return result, nil
+}
+
+// taskPending checks if the operation is still in progress. Task monitors
+// return 202 until the operation is done, while polling the task resource
+// itself returns its current state.
+func taskPending(resp *http.Response, result *TaskResult) bool {
+	return resp.StatusCode == http.StatusAccepted || (result.Task != nil && result.Task.Running())
+}
+
+// readTaskResponse reads the body and headers of a response that may describe
+// a task.
+func readTaskResponse(resp *http.Response) (*TaskResult, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TaskResult{Location: resp.Header.Get("Location"), Body: body}
+
+	task := &TaskStatus{}
+	if len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, task) == nil && task.TaskState != "" {
+		result.Task = task
+	}
+
+	return result, nil
+}
+
+// taskMessages joins the messages reported by a task.
+func taskMessages(c common.Client, task *TaskStatus) string {
+	registries := NewMessageRegistries(c)
+	messages := []string{}
+	for i := range task.Messages {
+		message := &task.Messages[i]
+		if message.Message != "" || message.MessageID != "" {
+			messages = append(messages, registries.Message(message.MessageID, message.Message, message.MessageArgs))
+		}
+	}
+
+	if len(messages) == 0 {
+		return "no details provided"
+	}
+
+	return strings.Join(messages, "; ")
+}
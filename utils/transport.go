@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/stmcginnis/gofish"
+
+	"github.com/stmcginnis/ctlfish/config"
+)
+
+// ClientOptions are the settings from the global command line flags that
+// apply to every connection.
+type ClientOptions struct {
+	// RecordFile is where requests and responses are saved, if set.
+	RecordFile string
+	// ReplayFile is a recording to answer requests from instead of the
+	// service, if set.
+	ReplayFile string
+	// DebugLevel controls the logging of requests, see DebugRequests and
+	// DebugBodies. Nothing is logged if it is 0.
+	DebugLevel int
+	// LogFile is where debug logs are written instead of stderr, if set.
+	LogFile string
+
+	// Timeout, ConnectTimeout, Retries and RateLimit override the settings
+	// of every connection, if set.
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+	Retries        *int
+	RateLimit      float64
+}
+
+// Options holds the global connection settings. It is set by the root command
+// before any command runs.
+var Options ClientOptions
+
+// setupHTTPClient builds the HTTP client for a connection, applying its proxy,
+// jump host, timeouts, retries and rate limit, and adding logging, recording
+// or replaying if requested. When replaying, the settings may be nil and the
+// endpoint is set to the one the recording was made from. The registries of
+// the returned error messages should be set up once the client is connected.
+func setupHTTPClient(cfg *gofish.ClientConfig, settings *config.SystemConfig) (*errorMessages, error) {
+	limits := limitsFor(settings)
+	proxy, err := proxyFor(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dialerFor(settings, limits.connectTimeout),
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: cfg.Insecure, //nolint:gosec // Verification is configured per connection
+		},
+	}
+
+	if Options.ReplayFile != "" {
+		transport, cfg.Endpoint, err = loadRecording(Options.ReplayFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if Options.DebugLevel > 0 {
+		transport, err = debugTransport(Options.DebugLevel, Options.LogFile, transport)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if Options.RecordFile != "" {
+		transport, err = recordingTransport(Options.RecordFile, transport)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Retries are outermost so each attempt is logged and recorded
+	transport = retryTransport(limits, transport)
+	transport = &sessionKeeper{next: transport}
+	// Messages are filled in last so the responses are logged and recorded as
+	// the service sent them
+	messages := &errorMessages{next: transport}
+
+	cfg.HTTPClient = &http.Client{Transport: messages}
+	return messages, nil
+}
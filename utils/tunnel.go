@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/stmcginnis/ctlfish/config"
+)
+
+// ProxyURL parses the proxy setting of a connection. HTTP, HTTPS and SOCKS5
+// proxies are supported.
+func ProxyURL(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, Error("invalid proxy '%s', expected a URL such as socks5://host:1080", proxy)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return proxyURL, nil
+	}
+
+	return nil, Error("unsupported proxy protocol '%s', must be http, https or socks5", proxyURL.Scheme)
+}
+
+// proxyFor gets the proxy function for a connection. The standard proxy
+// environment variables are used if the connection has no proxy set.
+func proxyFor(settings *config.SystemConfig) (func(*http.Request) (*url.URL, error), error) {
+	if settings == nil || settings.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := ProxyURL(settings.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// dialFunc opens network connections for a transport.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dialerFor gets how to open connections for a connection, either directly or
+// through its SSH jump host.
+func dialerFor(settings *config.SystemConfig, timeout time.Duration) dialFunc {
+	direct := (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	if settings == nil || settings.JumpHost == "" {
+		return direct
+	}
+
+	jump := &jumpHost{
+		address: settings.JumpHost,
+		user:    settings.JumpUser,
+		keyFile: settings.JumpKey,
+		timeout: timeout,
+	}
+	return jump.DialContext
+}
+
+var (
+	tunnelsLock sync.Mutex
+	tunnels     = map[string]*ssh.Client{}
+)
+
+// jumpHost opens connections through an SSH server. The SSH connection is
+// made on first use and shared by every connection using the same jump host,
+// until it is closed.
+type jumpHost struct {
+	address string
+	user    string
+	keyFile string
+	timeout time.Duration
+}
+
+// DialContext opens a connection to the address from the jump host.
+func (j *jumpHost) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, err := j.client()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.DialContext(ctx, network, address)
+	var openErr *ssh.OpenChannelError
+	if err != nil && !errors.As(err, &openErr) && ctx.Err() == nil {
+		// The jump host refusing the address leaves the SSH connection
+		// working, but any other failure means it has to be made again
+		forgetTunnel(client)
+		client, err = j.client()
+		if err != nil {
+			return nil, err
+		}
+		conn, err = client.DialContext(ctx, network, address)
+	}
+	if err != nil {
+		return nil, Error("unable to reach %s from jump host %s: %v", address, j.address, err)
+	}
+
+	return conn, nil
+}
+
+// client gets the SSH connection to the jump host, connecting if needed.
+func (j *jumpHost) client() (*ssh.Client, error) {
+	address := j.address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+
+	username := j.user
+	if username == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, Error("unable to get the jump host user: %v", err)
+		}
+		username = current.Username
+	}
+
+	key := strings.Join([]string{username, address, j.keyFile}, "\x00")
+	tunnelsLock.Lock()
+	defer tunnelsLock.Unlock()
+	if client, ok := tunnels[key]; ok {
+		return client, nil
+	}
+
+	auth, closeAgent, err := j.auth()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	hostKeys, err := knownHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            username,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         j.timeout,
+	})
+	if err != nil {
+		return nil, Error("unable to connect to jump host %s: %v", address, err)
+	}
+
+	tunnels[key] = client
+	go func() {
+		_ = client.Wait()
+		forgetTunnel(client)
+	}()
+
+	return client, nil
+}
+
+// forgetTunnel closes an SSH connection to a jump host, so the next
+// connection through the jump host makes a new one.
+func forgetTunnel(client *ssh.Client) {
+	tunnelsLock.Lock()
+	for key, tunnel := range tunnels {
+		if tunnel == client {
+			delete(tunnels, key)
+		}
+	}
+	tunnelsLock.Unlock()
+
+	_ = client.Close()
+}
+
+// auth gets how to log in to the jump host, using the key file if set or the
+// SSH agent otherwise. The returned function closes the connection to the
+// agent once logged in.
+func (j *jumpHost) auth() ([]ssh.AuthMethod, func(), error) {
+	if j.keyFile != "" {
+		keyFile, err := homedir.Expand(j.keyFile)
+		if err != nil {
+			return nil, nil, Error("invalid jump host key file '%s': %v", j.keyFile, err)
+		}
+
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, Error("unable to read jump host key: %v", err)
+		}
+
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return nil, nil, Error("unable to load jump host key '%s': %v", j.keyFile, err)
+		}
+
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, func() {}, nil
+	}
+
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil, Error("no key file is set for jump host %s and no SSH agent is running", j.address)
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, Error("unable to connect to the SSH agent: %v", err)
+	}
+
+	closeAgent := func() { _ = conn.Close() }
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, closeAgent, nil
+}
+
+// knownHosts verifies jump hosts against the user's known_hosts file.
+func knownHosts() (ssh.HostKeyCallback, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, Error("unable to find the known_hosts file: %v", err)
+	}
+
+	file := filepath.Join(home, ".ssh", "known_hosts")
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, Error("unable to load %s, connect to the jump host with ssh once to add its key: %v", file, err)
+	}
+
+	return callback, nil
+}
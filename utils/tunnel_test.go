@@ -0,0 +1,320 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/stmcginnis/ctlfish/config"
+	"github.com/stmcginnis/ctlfish/mockup/mockuptest"
+	"github.com/stmcginnis/ctlfish/utils"
+)
+
+// socksProxy is a minimal SOCKS5 proxy that counts the connections made
+// through it.
+func socksProxy(t *testing.T, connections *atomic.Int32) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := socksHandshake(conn)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+				connections.Add(1)
+
+				go func() { _, _ = io.Copy(target, conn) }()
+				_, _ = io.Copy(conn, target)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// socksHandshake accepts a client without authentication and connects to the
+// address it requests.
+func socksHandshake(conn net.Conn) (net.Conn, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return nil, err
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return nil, err
+	}
+
+	var host string
+	switch request[3] {
+	case 1:
+		addr := make([]byte, 4)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return nil, err
+		}
+		host = net.IP(addr).String()
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, err
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return nil, err
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+
+	_, err = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	return target, err
+}
+
+func TestProxy(t *testing.T) {
+	mockuptest.Start(t)
+	connections := &atomic.Int32{}
+	proxy := socksProxy(t, connections)
+
+	settings := config.GetDefaultSystem()
+	settings.Proxy = "socks5://" + proxy
+	if err := config.AddSystemConfig(config.GetDefault(), settings, true); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := utils.GofishClient("")
+	if err != nil {
+		t.Fatalf("unable to connect through the proxy: %v", err)
+	}
+	defer c.Logout()
+
+	if _, err := c.Service.Systems(); err != nil {
+		t.Errorf("unable to get systems through the proxy: %v", err)
+	}
+
+	if connections.Load() == 0 {
+		t.Error("expected the connection to go through the proxy")
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	for _, proxy := range []string{"socks5://bastion:1080", "http://proxy:3128", "https://proxy"} {
+		if _, err := utils.ProxyURL(proxy); err != nil {
+			t.Errorf("expected %s to be accepted: %v", proxy, err)
+		}
+	}
+
+	for _, proxy := range []string{"bastion:1080", "ftp://proxy", "://"} {
+		if _, err := utils.ProxyURL(proxy); err == nil {
+			t.Errorf("expected %s to be rejected", proxy)
+		}
+	}
+}
+
+// jumpServer is a minimal SSH server that forwards connections, for testing
+// jump hosts.
+type jumpServer struct {
+	address string
+	keyFile string
+	mu      sync.Mutex
+	conns   []*ssh.ServerConn
+}
+
+// startJumpServer starts an SSH server, with a key file to log in with and a
+// known_hosts file that trusts it in a new home directory.
+func startJumpServer(t *testing.T) *jumpServer {
+	t.Helper()
+
+	_, hostKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPublic, userKey, _ := ed25519.GenerateKey(rand.Reader)
+	authorized, _ := ssh.NewPublicKey(userPublic)
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, io.EOF
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	server := &jumpServer{address: listener.Addr().String()}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	homedir.DisableCache = true
+	t.Cleanup(func() { homedir.DisableCache = false })
+
+	block, _ := ssh.MarshalPrivateKey(userKey, "")
+	server.keyFile = filepath.Join(home, "jump_key")
+	knownHosts := knownhosts.Line([]string{server.address}, hostSigner.PublicKey()) + "\n"
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(server.keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(knownHosts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn, serverConfig)
+		}
+	}()
+
+	return server
+}
+
+// serve forwards the connections requested by an SSH client.
+func (s *jumpServer) serve(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, serverConn)
+	s.mu.Unlock()
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		forward := struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}{}
+		if newChannel.ChannelType() != "direct-tcpip" || ssh.Unmarshal(newChannel.ExtraData(), &forward) != nil {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "only forwarding is supported")
+			continue
+		}
+
+		target, err := net.Dial("tcp", net.JoinHostPort(forward.Host, strconv.Itoa(int(forward.Port))))
+		if err != nil {
+			_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go func() { _, _ = io.Copy(target, channel) }()
+			_, _ = io.Copy(channel, target)
+		}()
+	}
+}
+
+// connections gets how many SSH connections have been made.
+func (s *jumpServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// drop closes the SSH connections, as if the jump host restarted.
+func (s *jumpServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func TestJumpHostReconnect(t *testing.T) {
+	mockuptest.Start(t)
+	jump := startJumpServer(t)
+
+	settings := config.GetDefaultSystem()
+	settings.Proxy = ""
+	settings.JumpHost = jump.address
+	settings.JumpUser = "tester"
+	settings.JumpKey = jump.keyFile
+	if err := config.AddSystemConfig(config.GetDefault(), settings, true); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := utils.GofishClient("")
+	if err != nil {
+		t.Fatalf("unable to connect through the jump host: %v", err)
+	}
+	defer c.Logout()
+
+	if _, err := c.Service.Systems(); err != nil {
+		t.Fatalf("unable to get systems through the jump host: %v", err)
+	}
+
+	// Losing the SSH connection means a new one is made
+	jump.drop()
+	if _, err := c.Service.Systems(); err != nil {
+		t.Fatalf("unable to get systems after the jump host connection was lost: %v", err)
+	}
+
+	if count := jump.connections(); count != 2 {
+		t.Errorf("expected 2 SSH connections, got %d", count)
+	}
+}
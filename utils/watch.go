@@ -0,0 +1,494 @@
+// SPDX-License-Identifier: BSD-3-Clause
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// defaultWatchInterval is used when --watch is given without an interval.
+const defaultWatchInterval = 2 * time.Second
+
+// watchDefault is the value of --watch when it is given without an interval.
+// It is not a duration, so it can be told apart from an interval that happens
+// to be the same as the default.
+const watchDefault = "default"
+
+// watchValue is the value of the --watch flag, which records whether an
+// interval was given.
+type watchValue struct {
+	interval time.Duration
+	explicit bool
+}
+
+func (v *watchValue) Set(value string) error {
+	if value == watchDefault {
+		v.interval = defaultWatchInterval
+		v.explicit = false
+		return nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	v.interval = interval
+	v.explicit = true
+
+	return nil
+}
+
+func (v *watchValue) String() string {
+	if v.interval == 0 {
+		return "0"
+	}
+	return v.interval.String()
+}
+
+func (v *watchValue) Type() string {
+	return "duration"
+}
+
+// AddWatchFlags adds the --watch flags to a command and its subcommands. Their
+// RunE must be wrapped with Watchable for the flags to have any effect.
+func AddWatchFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Var(&watchValue{}, "watch",
+		"Rerun every `INTERVAL`, highlighting changes, until interrupted. The interval is 2s if not given, "+
+			"and may also follow the flag.")
+	cmd.PersistentFlags().Lookup("watch").NoOptDefVal = watchDefault
+	cmd.PersistentFlags().Bool("watch-json", false,
+		"With --watch, print a JSON line for each row that changes instead of redrawing the tables.")
+}
+
+// WatchableArgs wraps the argument validation of a command so an interval
+// following --watch is not taken as an argument.
+func WatchableArgs(validate cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		_, args = watchInterval(cmd, args)
+		if validate == nil {
+			return nil
+		}
+		return validate(cmd, args)
+	}
+}
+
+// Watchable wraps a command so that with --watch it is run repeatedly, showing
+// what changed in its tables each time.
+func Watchable(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("watch") {
+			return run(cmd, args)
+		}
+
+		interval, args := watchInterval(cmd, args)
+		if interval <= 0 {
+			return ErrorExit(cmd, "the watch interval must be more than 0")
+		}
+		jsonLines, _ := cmd.Flags().GetBool("watch-json")
+
+		w := &watcher{cmd: cmd, args: args, run: run, interval: interval, out: cmd.OutOrStdout()}
+		return w.watch(jsonLines)
+	}
+}
+
+// watchInterval gets the interval to watch at. When --watch was given without
+// a value, an argument that is a duration is used as the interval and removed.
+func watchInterval(cmd *cobra.Command, args []string) (time.Duration, []string) {
+	flag := cmd.Flags().Lookup("watch")
+	if flag == nil || !flag.Changed {
+		return 0, args
+	}
+
+	value, ok := flag.Value.(*watchValue)
+	if !ok {
+		return 0, args
+	}
+	interval := value.interval
+	if value.explicit {
+		return interval, args
+	}
+
+	for i := len(args) - 1; i >= 0; i-- {
+		if value, err := time.ParseDuration(args[i]); err == nil && value > 0 {
+			remaining := append(append([]string{}, args[:i]...), args[i+1:]...)
+			return value, remaining
+		}
+	}
+
+	return interval, args
+}
+
+// watcher reruns a command, showing the changes in its output.
+type watcher struct {
+	cmd      *cobra.Command
+	args     []string
+	run      func(*cobra.Command, []string) error
+	interval time.Duration
+	out      io.Writer
+}
+
+// watch polls until interrupted. A single session is used for all of the
+// polls, and is only logged out at the end.
+func (w *watcher) watch(jsonLines bool) error {
+	release := KeepSessions()
+	defer func() { release() }()
+
+	parent := w.cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(parent, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Each poll captures the output of the command, afterwards it goes back to
+	// using the output of its parent
+	defer w.cmd.SetOut(nil)
+
+	var previous []*capturedTable
+	lastError := ""
+	for {
+		tables, text, err := w.poll()
+
+		errorMessage := ""
+		if err != nil {
+			errorMessage = err.Error()
+		}
+
+		if jsonLines {
+			if errorMessage != lastError {
+				w.writeJSON(map[string]interface{}{"time": time.Now().Format(time.RFC3339), "error": errorMessage})
+			}
+			if err == nil {
+				w.writeChanges(previous, tables)
+			}
+		} else {
+			w.redraw(previous, tables, text, errorMessage)
+		}
+
+		// Changes are shown against the last successful poll, so a service that
+		// is briefly unreachable does not make everything look new. The session
+		// may have expired, so the next poll logs in again.
+		if err == nil {
+			previous = tables
+		} else {
+			release()
+			release = KeepSessions()
+		}
+		lastError = errorMessage
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// poll runs the command once, capturing its tables and any other output.
+func (w *watcher) poll() ([]*capturedTable, string, error) {
+	output := &bytes.Buffer{}
+	w.cmd.SetOut(output)
+
+	capture := startCapture()
+	err := w.run(w.cmd, w.args)
+	stopCapture()
+
+	// Health checks report through their exit code, which is not a failure here
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		err = nil
+	}
+
+	return capture.rendered, output.String(), err
+}
+
+// redraw shows the output, highlighting the table cells that changed. On a
+// terminal the screen is cleared first so the tables stay in place.
+func (w *watcher) redraw(previous, tables []*capturedTable, text, errorMessage string) {
+	color := isTerminal(w.out)
+	frame := &bytes.Buffer{}
+	if color {
+		frame.WriteString("\033[H\033[2J")
+	}
+
+	command := strings.Join(append([]string{w.cmd.CommandPath()}, w.args...), " ")
+	fmt.Fprintf(frame, "Every %s: %s\t%s\n\n", w.interval, command, time.Now().Format(time.DateTime))
+
+	if errorMessage != "" {
+		fmt.Fprintf(frame, "Error: %s\n\n", errorMessage)
+	}
+
+	// Tables go back where the command rendered them, between anything else
+	// it printed
+	parts := strings.Split(text, tableMarker)
+	frame.WriteString(parts[0])
+	for i, table := range tables {
+		table.renderChanges(frame, previousTable(previous, i, table), color)
+		if i+1 < len(parts) {
+			frame.WriteString(parts[i+1])
+		}
+	}
+
+	_, _ = w.out.Write(frame.Bytes())
+}
+
+// writeChanges prints a JSON line for each row that is new, changed or gone
+// since the previous poll.
+func (w *watcher) writeChanges(previous, tables []*capturedTable) {
+	now := time.Now().Format(time.RFC3339)
+	for i, table := range tables {
+		before := previousTable(previous, i, table)
+		oldRows := map[string][]string{}
+		if before != nil {
+			oldRows = before.rowsByKey()
+		}
+
+		for j, key := range table.rowKeys() {
+			row := table.rows[j]
+			line := map[string]interface{}{"time": now, "row": table.rowMap(row)}
+			if len(tables) > 1 {
+				line["table"] = i
+			}
+
+			old, existed := oldRows[key]
+			delete(oldRows, key)
+			if before != nil {
+				changed := table.changedColumns(old, row, existed)
+				if len(changed) == 0 {
+					continue
+				}
+				line["changed"] = changed
+			}
+			w.writeJSON(line)
+		}
+
+		if before == nil {
+			continue
+		}
+		for _, key := range before.rowKeys() {
+			row, gone := oldRows[key]
+			if !gone {
+				continue
+			}
+			line := map[string]interface{}{"time": now, "row": before.rowMap(row), "removed": true}
+			if len(tables) > 1 {
+				line["table"] = i
+			}
+			w.writeJSON(line)
+		}
+	}
+}
+
+func (w *watcher) writeJSON(line map[string]interface{}) {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(w.out, string(data))
+}
+
+// previousTable gets the table from the previous poll to compare a table
+// with. Tables are matched by position, as long as they have the same columns.
+func previousTable(previous []*capturedTable, i int, table *capturedTable) *capturedTable {
+	if i >= len(previous) || strings.Join(previous[i].headers, "\x00") != strings.Join(table.headers, "\x00") {
+		return nil
+	}
+
+	return previous[i]
+}
+
+// tableMarker is written to the output in place of each captured table.
+const tableMarker = "\x00table\x00"
+
+// capturedTable is a table a command rendered while being watched.
+type capturedTable struct {
+	out        io.Writer
+	headers    []string
+	rows       [][]string
+	severities []Severity
+	capture    *tableCapture
+}
+
+func (t *capturedTable) SetHeaders(headers ...string) {
+	t.headers = headers
+}
+
+func (t *capturedTable) AddRow(items ...interface{}) {
+	t.AddHighlightedRow(SeverityOK, items...)
+}
+
+func (t *capturedTable) AddHighlightedRow(severity Severity, items ...interface{}) {
+	t.rows = append(t.rows, toStrings(items))
+	t.severities = append(t.severities, severity)
+}
+
+func (t *capturedTable) Render() {
+	t.capture.rendered = append(t.capture.rendered, t)
+	fmt.Fprint(t.out, tableMarker)
+}
+
+func (t *capturedTable) RowCount() int {
+	return len(t.rows)
+}
+
+// rowKeys identifies each row by its first column, numbering repeats, so rows
+// can be matched between polls even if the order changes.
+func (t *capturedTable) rowKeys() []string {
+	keys := []string{}
+	seen := map[string]int{}
+	for _, row := range t.rows {
+		first := ""
+		if len(row) > 0 {
+			first = row[0]
+		}
+		keys = append(keys, fmt.Sprintf("%s\x00%d", first, seen[first]))
+		seen[first]++
+	}
+
+	return keys
+}
+
+func (t *capturedTable) rowsByKey() map[string][]string {
+	rows := map[string][]string{}
+	for i, key := range t.rowKeys() {
+		rows[key] = t.rows[i]
+	}
+
+	return rows
+}
+
+// rowMap names the cells of a row by their column headers.
+func (t *capturedTable) rowMap(row []string) map[string]string {
+	result := map[string]string{}
+	for i, value := range row {
+		if i < len(t.headers) {
+			result[t.headers[i]] = value
+		}
+	}
+
+	return result
+}
+
+// changedColumns gets the headers of the cells that differ between the old and
+// new version of a row. Every column has changed for new rows.
+func (t *capturedTable) changedColumns(old, row []string, existed bool) []string {
+	changed := []string{}
+	for i, value := range row {
+		if i >= len(t.headers) {
+			break
+		}
+		if !existed || i >= len(old) || old[i] != value {
+			changed = append(changed, t.headers[i])
+		}
+	}
+
+	return changed
+}
+
+// renderChanges draws the table, highlighting the cells that differ from the
+// previous version of it.
+func (t *capturedTable) renderChanges(out io.Writer, previous *capturedTable, color bool) {
+	writer, _ := NewTableWriter(out, t.headers...).(*tableoutputwriter)
+	writer.color = color
+
+	oldRows := map[string][]string{}
+	if previous != nil {
+		oldRows = previous.rowsByKey()
+	}
+
+	for i, key := range t.rowKeys() {
+		row := t.rows[i]
+		if !color {
+			writer.AddHighlightedRow(t.severities[i], toInterfaces(row)...)
+			continue
+		}
+
+		old, existed := oldRows[key]
+		colors := []tablewriter.Colors{}
+		for j, value := range row {
+			cell := severityColor(t.severities[i])
+			if previous != nil && (!existed || j >= len(old) || old[j] != value) {
+				cell = append(cell, reverseVideo)
+			}
+			colors = append(colors, cell)
+		}
+		writer.table.Rich(row, colors)
+	}
+
+	writer.Render()
+}
+
+// reverseVideo swaps the foreground and background colors of changed cells.
+const reverseVideo = 7
+
+// severityColor gets the color used for rows of a severity.
+func severityColor(severity Severity) tablewriter.Colors {
+	switch severity {
+	case SeverityWarning:
+		return tablewriter.Colors{tablewriter.FgYellowColor}
+	case SeverityCritical:
+		return tablewriter.Colors{tablewriter.Bold, tablewriter.FgRedColor}
+	default:
+		return tablewriter.Colors{}
+	}
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := []interface{}{}
+	for _, value := range values {
+		result = append(result, value)
+	}
+
+	return result
+}
+
+// tableCapture collects the tables rendered while a watched command runs,
+// instead of printing them.
+type tableCapture struct {
+	rendered []*capturedTable
+}
+
+var (
+	captureLock   sync.Mutex
+	activeCapture *tableCapture
+)
+
+func startCapture() *tableCapture {
+	captureLock.Lock()
+	defer captureLock.Unlock()
+
+	activeCapture = &tableCapture{}
+	return activeCapture
+}
+
+func stopCapture() {
+	captureLock.Lock()
+	defer captureLock.Unlock()
+
+	activeCapture = nil
+}
+
+// capturedTableWriter gets a table writer that captures the table, if tables
+// are being captured.
+func capturedTableWriter(output io.Writer, headers []string) TableOutputWriter {
+	captureLock.Lock()
+	defer captureLock.Unlock()
+
+	if activeCapture == nil {
+		return nil
+	}
+
+	return &capturedTable{out: output, headers: headers, capture: activeCapture}
+}